@@ -0,0 +1,38 @@
+package modifier
+
+import (
+	"context"
+	"reflect"
+)
+
+// Apply applies the provided transformations against v and returns the result,
+// which allows conforming one-off values inline e. g.
+//
+//	q, err := modifier.Apply(ctx, conform, r.URL.Query().Get("q"), "trim,lcase")
+//
+// Tags are cached the same way as for Transformer.Field.
+func Apply[T any](ctx context.Context, t *Transformer, v T, tags string) (T, error) {
+	if err := t.Field(ctx, &v, tags); err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
+
+// ApplyStruct applies transformations against the provided struct, or pointer to struct, and returns it.
+// A struct passed by value is transformed as a shallow copy,
+// pointers, slices and maps within it are still shared with the caller, use Clone for a deep copy.
+func ApplyStruct[T any](ctx context.Context, t *Transformer, v T) (T, error) {
+	var err error
+	if reflect.ValueOf(v).Kind() == reflect.Ptr {
+		err = t.Struct(ctx, v)
+	} else {
+		err = t.Struct(ctx, &v)
+	}
+
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
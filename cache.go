@@ -12,13 +12,27 @@ const (
 	typeDive
 	typeKeys
 	typeEndKeys
+	typeOmitEmpty
+	typeOmitNil
+	typeIf
+	typeUnless
+)
+
+const (
+	moldNever moldCheck = iota
+	moldAlways
+	moldDynamic
 )
 
 type tagType uint8
 
+// moldCheck is whether the values of a struct field implement Moldable.
+type moldCheck uint8
+
 type cTag struct {
 	tag            string
 	param          string
+	params         Params
 	aliasTag       string
 	actualAliasTag string
 	hasAlias       bool
@@ -26,32 +40,46 @@ type cTag struct {
 	fn             Func
 	keys           *cTag
 	next           *cTag
+	or             *cTag
 	typeof         tagType
 }
 
 type cField struct {
-	idx   int
-	cTags *cTag
+	idx     int
+	name    string
+	altName string
+	fld     reflect.StructField
+	cTags   *cTag
+	mold    moldCheck
 }
 
 type cStruct struct {
-	fields []*cField
-	fn     StructLevelFunc
+	name      string
+	fields    []*cField
+	fn        StructLevelFunc
+	generated bool
+	moldable  bool
+}
+
+// structKey identifies a parsed struct type by the type and the groups whose tags were applied.
+type structKey struct {
+	typ    reflect.Type
+	groups string
 }
 
 type structCache struct {
 	lock sync.Mutex
-	m    atomic.Value // map[reflect.Type]*cStruct
+	m    atomic.Value // map[structKey]*cStruct
 }
 
-func (sc *structCache) Get(key reflect.Type) (c *cStruct, found bool) {
-	c, found = sc.m.Load().(map[reflect.Type]*cStruct)[key]
+func (sc *structCache) Get(key structKey) (c *cStruct, found bool) {
+	c, found = sc.m.Load().(map[structKey]*cStruct)[key]
 	return
 }
 
-func (sc *structCache) Set(key reflect.Type, value *cStruct) {
-	m := sc.m.Load().(map[reflect.Type]*cStruct)
-	nm := make(map[reflect.Type]*cStruct, len(m)+1)
+func (sc *structCache) Set(key structKey, value *cStruct) {
+	m := sc.m.Load().(map[structKey]*cStruct)
+	nm := make(map[structKey]*cStruct, len(m)+1)
 	for k, v := range m {
 		nm[k] = v
 	}
@@ -60,6 +88,32 @@ func (sc *structCache) Set(key reflect.Type, value *cStruct) {
 	sc.m.Store(nm)
 }
 
+// moldCache caches whether values of a type implement Moldable.
+type moldCache struct {
+	lock sync.Mutex
+	m    atomic.Value // map[reflect.Type]bool
+}
+
+func (mc *moldCache) moldable(typ reflect.Type) bool {
+	moldable, ok := mc.m.Load().(map[reflect.Type]bool)[typ]
+	if ok {
+		return moldable
+	}
+
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	m := mc.m.Load().(map[reflect.Type]bool)
+	nm := make(map[reflect.Type]bool, len(m)+1)
+	for k, v := range m {
+		nm[k] = v
+	}
+
+	moldable = isMoldable(typ)
+	nm[typ] = moldable
+	mc.m.Store(nm)
+	return moldable
+}
+
 type tagCache struct {
 	lock sync.Mutex
 	m    atomic.Value // map[string]*cTag
@@ -81,11 +135,13 @@ func (tc *tagCache) Set(key string, value *cTag) {
 	tc.m.Store(nm)
 }
 
-func (t *Transformer) parseFieldTagsRecursive(tag string, fieldName string, alias string, hasAlias bool) (firstCtag *cTag, current *cTag, err error) {
+// parseFieldTagsRecursive parses tag into the chain of cTags, expanding the registered aliases,
+// expanding holds the aliases tag is the expansion of to detect aliases referring to themselves.
+func (t *Transformer) parseFieldTagsRecursive(tag string, fieldName string, alias string, hasAlias bool, expanding []string) (firstCtag *cTag, current *cTag, err error) {
 	var tg string
 	var ok bool
 	noAlias := len(alias) == 0
-	tags := strings.Split(tag, tagSeparator)
+	tags := splitTags(tag, tagSeparator[0])
 	for i := 0; i < len(tags); i++ {
 		tg = tags[i]
 		if noAlias {
@@ -94,13 +150,22 @@ func (t *Transformer) parseFieldTagsRecursive(tag string, fieldName string, alia
 
 		// check map for alias and process new tags, otherwise process as usual
 		if tagsVal, found := t.aliases[tg]; found {
+			// the expansions of different tags must not share the backing array
+			chain := append(expanding[:len(expanding):len(expanding)], tg)
+			for _, a := range expanding {
+				if a == tg {
+					err = &ErrAliasCycle{aliases: chain, field: fieldName}
+					return
+				}
+			}
+
 			if i == 0 {
-				firstCtag, current, err = t.parseFieldTagsRecursive(tagsVal, fieldName, tg, true)
+				firstCtag, current, err = t.parseFieldTagsRecursive(tagsVal, fieldName, tg, true, chain)
 				if err != nil {
 					return
 				}
 			} else {
-				next, curr, e := t.parseFieldTagsRecursive(tagsVal, fieldName, tg, true)
+				next, curr, e := t.parseFieldTagsRecursive(tagsVal, fieldName, tg, true, chain)
 				if e != nil {
 					err = e
 					return
@@ -144,10 +209,16 @@ func (t *Transformer) parseFieldTagsRecursive(tag string, fieldName string, alia
 				}
 			}
 
-			if current.keys, _, err = t.parseFieldTagsRecursive(string(b[:len(b)-1]), fieldName, "", false); err != nil {
+			if current.keys, _, err = t.parseFieldTagsRecursive(string(b[:len(b)-1]), fieldName, "", false, expanding); err != nil {
 				return
 			}
 
+			continue
+		case omitEmptyTag:
+			current.typeof = typeOmitEmpty
+			continue
+		case omitNilTag:
+			current.typeof = typeOmitNil
 			continue
 		case endKeysTag:
 			current.typeof = typeEndKeys
@@ -157,6 +228,13 @@ func (t *Transformer) parseFieldTagsRecursive(tag string, fieldName string, alia
 			}
 			return
 		default:
+			if orTags := splitTags(tg, orSeparator[0]); len(orTags) > 1 {
+				if err = t.parseOrTags(current, tg, orTags, fieldName, alias, noAlias); err != nil {
+					return
+				}
+				continue
+			}
+
 			vals := strings.SplitN(tg, tagKeySeparator, 2)
 			if noAlias {
 				alias = vals[0]
@@ -171,35 +249,153 @@ func (t *Transformer) parseFieldTagsRecursive(tag string, fieldName string, alia
 				return
 			}
 
+			if len(vals) > 1 {
+				current.param, current.params = parseParam(vals[1])
+			}
+
+			switch current.tag {
+			case ifTag, unlessTag:
+				current.typeof = typeIf
+				if current.tag == unlessTag {
+					current.typeof = typeUnless
+				}
+
+				// guards need the name of the field to evaluate
+				if len(current.param) == 0 || current.param[0] == guardSeparator {
+					err = &ErrInvalidTag{tag: tg, field: fieldName}
+					return
+				}
+				continue
+			}
+
 			if current.fn, ok = t.transformations[current.tag]; !ok {
 				err = &ErrUndefinedTag{tag: current.tag, field: fieldName}
 				return
 			}
-
-			if len(vals) > 1 {
-				current.param = strings.Replace(vals[1], utf8HexComma, ",", -1)
-			}
+			current.fn = t.wrap(current.tag, current.param, current.fn)
 		}
 	}
 
 	return
 }
 
-func (t *Transformer) extractStructCache(current reflect.Value) (cs *cStruct, err error) {
+// parseOrTags parses an OR group e. g. a|b=param, split into orTags, into current and its alternatives.
+// Only transformations may be used within OR groups.
+func (t *Transformer) parseOrTags(current *cTag, tg string, orTags []string, fieldName, alias string, noAlias bool) error {
+	ct := current
+	for i, orTag := range orTags {
+		if i > 0 {
+			ct.or = &cTag{aliasTag: alias, hasAlias: current.hasAlias, hasTag: true}
+			ct = ct.or
+		}
+
+		vals := strings.SplitN(orTag, tagKeySeparator, 2)
+		ct.tag = vals[0]
+		if noAlias {
+			ct.aliasTag = ct.tag
+		} else {
+			ct.actualAliasTag = orTag
+		}
+
+		if len(ct.tag) == 0 {
+			return &ErrInvalidTag{tag: tg, field: fieldName}
+		}
+
+		if _, ok := restrictedTags[ct.tag]; ok {
+			return &ErrInvalidTag{tag: tg, field: fieldName}
+		}
+
+		var ok bool
+		if ct.fn, ok = t.transformations[ct.tag]; !ok {
+			return &ErrUndefinedTag{tag: ct.tag, field: fieldName}
+		}
+
+		if len(vals) > 1 {
+			ct.param, ct.params = parseParam(vals[1])
+		}
+		ct.fn = t.wrap(ct.tag, ct.param, ct.fn)
+	}
+
+	return nil
+}
+
+// wrap wraps fn with the registered middleware, the first one being the outermost.
+func (t *Transformer) wrap(tag, param string, fn Func) Func {
+	for i := len(t.middleware) - 1; i >= 0; i-- {
+		fn = t.middleware[i](tag, param, fn)
+	}
+	return fn
+}
+
+// replaceHexChars replaces the hex representations of reserved characters within a param.
+func replaceHexChars(param string) string {
+	return strings.Replace(strings.Replace(param, utf8HexComma, ",", -1), utf8HexPipe, orSeparator, -1)
+}
+
+// extractStructCache returns the parsed struct type of current with the tags of groups applied,
+// parsing it if not found in the cache.
+func (t *Transformer) extractStructCache(current reflect.Value, groups []string) (cs *cStruct, err error) {
 	t.cCache.lock.Lock()
 	defer t.cCache.lock.Unlock()
 	typ := current.Type()
+	key := structKey{typ: typ, groups: strings.Join(groups, tagSeparator)}
 	// could have been multiple trying to access, but once first is done this ensures struct isn't parsed again
-	cs, ok := t.cCache.Get(typ)
+	cs, ok := t.cCache.Get(key)
 	if ok {
 		return cs, nil
 	}
 
+	cs, errs := t.parseStruct(typ, groups)
+	if len(errs) > 0 {
+		return nil, errs[0].err
+	}
+
+	t.cCache.Set(key, cs)
+	return cs, nil
+}
+
+// groupTags appends the tags of the field for the given groups, e. g. mod.create, to tag.
+// It returns false if the field is ignored by one of the groups.
+func (t *Transformer) groupTags(fld reflect.StructField, groups []string, tag *string) bool {
+	for _, group := range groups {
+		groupTag := fld.Tag.Get(t.tagName + string(namespaceSeparator) + group)
+		switch {
+		case groupTag == ignoreTag:
+			return false
+		case len(groupTag) == 0:
+		case len(*tag) == 0:
+			*tag = groupTag
+		default:
+			*tag += tagSeparator + groupTag
+		}
+	}
+	return true
+}
+
+// fieldError is an error found parsing the tags of a struct field.
+type fieldError struct {
+	fld reflect.StructField
+	err error
+}
+
+// parseStruct parses the tags of all fields of the struct type typ, with the tags of groups appended,
+// and returns the errors of all fields with invalid tags.
+func (t *Transformer) parseStruct(typ reflect.Type, groups []string) (cs *cStruct, errs []fieldError) {
+	var err error
 	var ctag *cTag
 	var tag string
 	var fld reflect.StructField
-	cs = &cStruct{fields: make([]*cField, 0), fn: t.structLevelFuncs[typ]}
-	numFields := current.NumField()
+	// generated code does not call the Mold methods of the values it transforms
+	// and does not detect cycles
+	cs = &cStruct{
+		name:     typ.Name(),
+		fields:   make([]*cField, 0),
+		fn:       t.structLevelFuncs[typ],
+		moldable: reflect.PointerTo(typ).Implements(moldableType),
+	}
+	cs.generated = reflect.PointerTo(typ).Implements(generatedType) && !containsMoldable(typ, make(map[reflect.Type]bool)) &&
+		!recursive(typ, make(map[reflect.Type]bool), make(map[reflect.Type]bool))
+	numFields := typ.NumField()
 	for i := 0; i < numFields; i++ {
 		fld = typ.Field(i)
 		if !fld.Anonymous && len(fld.PkgPath) > 0 {
@@ -207,7 +403,17 @@ func (t *Transformer) extractStructCache(current reflect.Value) (cs *cStruct, er
 		}
 
 		tag = fld.Tag.Get(t.tagName)
-		if tag == ignoreTag {
+		if rule, ok := t.structRules[typ][fld.Name]; ok {
+			if strings.HasPrefix(rule, ruleMergePrefix) {
+				rule = rule[len(ruleMergePrefix):]
+				if len(tag) > 0 && tag != ignoreTag && len(rule) > 0 {
+					rule = tag + tagSeparator + rule
+				}
+			}
+			tag = rule
+		}
+
+		if tag == ignoreTag || !t.groupTags(fld, groups, &tag) {
 			continue
 		}
 
@@ -215,9 +421,10 @@ func (t *Transformer) extractStructCache(current reflect.Value) (cs *cStruct, er
 		// but things like alias may be different and so only struct level caching can
 		// be used instead of combined with Field tag caching
 		if len(tag) > 0 {
-			ctag, _, err = t.parseFieldTagsRecursive(tag, fld.Name, "", false)
+			ctag, _, err = t.parseFieldTagsRecursive(tag, fld.Name, "", false, nil)
 			if err != nil {
-				return nil, err
+				errs = append(errs, fieldError{fld: fld, err: err})
+				continue
 			}
 		} else {
 			// even if field doesn't have validations need cTag for traversing to
@@ -225,12 +432,65 @@ func (t *Transformer) extractStructCache(current reflect.Value) (cs *cStruct, er
 			ctag = &cTag{typeof: typeDefault}
 		}
 
+		altName := fld.Name
+		if t.tagNameFunc != nil {
+			if name := t.tagNameFunc(fld); len(name) > 0 {
+				altName = name
+			}
+		}
+
 		cs.fields = append(cs.fields, &cField{
-			idx:   i,
-			cTags: ctag,
+			idx:     i,
+			name:    fld.Name,
+			altName: altName,
+			fld:     fld,
+			cTags:   ctag,
+			mold:    t.fieldMold(fld.Type),
 		})
 	}
 
-	t.cCache.Set(typ, cs)
-	return cs, nil
+	return cs, errs
+}
+
+// compile parses and caches the struct types reachable from typ,
+// the errors of invalid tags are appended to errs.
+func (t *Transformer) compile(typ reflect.Type, ns string, seen map[reflect.Type]bool, errs *CompileErrors) {
+	for {
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			typ = typ.Elem()
+			continue
+		}
+		break
+	}
+
+	if typ.Kind() != reflect.Struct || typ == timeType || seen[typ] {
+		return
+	}
+
+	seen[typ] = true
+	if len(ns) == 0 {
+		ns = typ.Name()
+	}
+
+	t.cCache.lock.Lock()
+	if _, ok := t.cCache.Get(structKey{typ: typ}); !ok {
+		cs, fieldErrs := t.parseStruct(typ, nil)
+		for _, fe := range fieldErrs {
+			*errs = append(*errs, &CompileError{ns: ns + string(namespaceSeparator) + fe.fld.Name, err: fe.err})
+		}
+
+		if len(fieldErrs) == 0 {
+			t.cCache.Set(structKey{typ: typ}, cs)
+		}
+	}
+	t.cCache.lock.Unlock()
+
+	// nested types are compiled even if the fields using them are invalid
+	for i := 0; i < typ.NumField(); i++ {
+		fld := typ.Field(i)
+		if (fld.Anonymous || len(fld.PkgPath) == 0) && fld.Tag.Get(t.tagName) != ignoreTag {
+			t.compile(fld.Type, ns+string(namespaceSeparator)+fld.Name, seen, errs)
+		}
+	}
 }
@@ -0,0 +1,77 @@
+package modifier
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// Change describes a value changed by a transformation function,
+// as returned by StructChanges and DryRun.
+type Change struct {
+	// Namespace of the changed value e. g. User.Address[1].Phone,
+	// field names are taken from the TagNameFunc when one is registered.
+	Namespace string
+	// StructNamespace of the changed value using the actual Go field names.
+	StructNamespace string
+	// Tag, or alias, of the transformation which changed the value.
+	Tag string
+	// ActualTag of the transformation, in case of an alias the actual tag within the alias.
+	ActualTag string
+	// Param of the transformation.
+	Param string
+	// Before is the value before the transformation with pointers dereferenced,
+	// or the hash of it when changes are redacted.
+	Before interface{}
+	// After is the value after the transformation with pointers dereferenced,
+	// or the hash of it when changes are redacted.
+	After interface{}
+}
+
+// changeValue returns the value to record for v, pointers and interfaces are dereferenced
+// and the value is copied so that it is not modified by subsequent transformations.
+func (tr *transform) changeValue(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if !v.CanInterface() {
+		return nil
+	}
+
+	if cp, err := tr.t.deepCopy(v, make(map[visitKey]reflect.Value)); err == nil {
+		v = cp
+	}
+	return v.Interface()
+}
+
+// recordChange records the change of the value at p by ct if the value was changed.
+func (tr *transform) recordChange(p fieldPath, ct *cTag, before, after interface{}) {
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+
+	if tr.t.redactChanges {
+		before, after = redact(before), redact(after)
+	}
+
+	tr.changes = append(tr.changes, Change{
+		Namespace:       p.ns(),
+		StructNamespace: p.structNs(),
+		Tag:             ct.aliasTag,
+		ActualTag:       ct.tag,
+		Param:           ct.param,
+		Before:          before,
+		After:           after,
+	})
+}
+
+// redact returns the hex encoded SHA-256 hash of the formatted value.
+func redact(v interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%#v", v)))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,878 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/types"
+	"math"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pchchv/modifier/modifiers"
+	"github.com/pchchv/modifier/scrubbers"
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	modifiersPath = "github.com/pchchv/modifier/modifiers"
+	scrubbersPath = "github.com/pchchv/modifier/scrubbers"
+)
+
+// config is the configuration of a generation.
+type config struct {
+	modTag   string
+	scrubTag string
+	types    []string
+	output   string
+}
+
+// result is the result of a generation.
+type result struct {
+	src       []byte
+	dir       string   // directory of the package
+	fallbacks []string // why types are transformed with reflection
+}
+
+// tagSet is a tag name along with the transformations which can be generated for it.
+type tagSet struct {
+	name     string
+	ident    string // identifier of the tag name within generated identifiers
+	pkgPath  string
+	funcs    map[string]bool
+	builtins bool // whether set, default and empty are available
+}
+
+type generator struct {
+	pkg         *types.Package
+	sets        []*tagSet
+	named       []*types.Named // the types to generate in source order
+	selected    map[*types.Named]bool
+	unsupported map[*tagSet]map[*types.Named]error
+	imports     map[string]string // import path to package name
+	vars        map[string]string // variable name to expression
+}
+
+// emitter emits the code of a tag set for a type.
+type emitter struct {
+	g      *generator
+	set    *tagSet
+	depth  int             // depth of the generated loops
+	nonNil map[string]bool // pointers known not to be nil
+}
+
+// generate loads the package matched by pattern and generates the code for its types.
+func generate(cfg config, pattern string) (*result, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+	}, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%d packages matching %q, expected 1", len(pkgs), pattern)
+	}
+
+	pkg := pkgs[0]
+	if len(pkg.GoFiles) == 0 {
+		return nil, fmt.Errorf("no Go files in package %s", pkg.PkgPath)
+	}
+
+	dir := filepath.Dir(pkg.GoFiles[0])
+	output := cfg.output
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(dir, output)
+	}
+
+	for _, e := range pkg.Errors {
+		// the previously generated code may be outdated
+		if !strings.HasPrefix(e.Pos, output+":") {
+			return nil, e
+		}
+	}
+
+	g := &generator{
+		pkg:         pkg.Types,
+		selected:    make(map[*types.Named]bool),
+		unsupported: make(map[*tagSet]map[*types.Named]error),
+		imports:     make(map[string]string),
+		vars:        make(map[string]string),
+	}
+	if len(cfg.modTag) > 0 {
+		set := &tagSet{name: cfg.modTag, pkgPath: modifiersPath, funcs: make(map[string]bool), builtins: true}
+		for tag := range modifiers.StringFuncs() {
+			set.funcs[tag] = true
+		}
+		g.sets = append(g.sets, set)
+	}
+
+	if len(cfg.scrubTag) > 0 {
+		set := &tagSet{name: cfg.scrubTag, pkgPath: scrubbersPath, funcs: make(map[string]bool)}
+		for tag := range scrubbers.StringFuncs() {
+			set.funcs[tag] = true
+		}
+		g.sets = append(g.sets, set)
+	}
+
+	if len(g.sets) == 2 && g.sets[0].name == g.sets[1].name {
+		return nil, fmt.Errorf("modifiers and scrubbers use the same tag name %q", g.sets[0].name)
+	}
+
+	for _, set := range g.sets {
+		set.ident = identifier(set.name)
+		g.unsupported[set] = make(map[*types.Named]error)
+	}
+
+	if err = g.selectTypes(cfg.types); err != nil {
+		return nil, err
+	}
+
+	res := &result{dir: dir}
+	g.resolve()
+	// only the code of the supported types is needed
+	g.imports, g.vars = make(map[string]string), make(map[string]string)
+	for _, set := range g.sets {
+		for _, n := range g.named {
+			if err := g.unsupported[set][n]; err != nil {
+				res.fallbacks = append(res.fallbacks, fmt.Sprintf("%s: %s tags are transformed with reflection: %s", n.Obj().Name(), set.name, err))
+			}
+		}
+	}
+
+	if res.src, err = g.file(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// selectTypes selects the named types and the struct types of the package they use.
+func (g *generator) selectTypes(names []string) error {
+	scope := g.pkg.Scope()
+	var candidates []*types.Named
+	if len(names) == 0 {
+		for _, name := range scope.Names() {
+			if n := g.localStruct(scope.Lookup(name).Type()); n != nil && g.tagged(n) {
+				candidates = append(candidates, n)
+			}
+		}
+	} else {
+		for _, name := range names {
+			obj := scope.Lookup(strings.TrimSpace(name))
+			if obj == nil {
+				return fmt.Errorf("type %s not found in package %s", name, g.pkg.Path())
+			}
+
+			n := g.localStruct(obj.Type())
+			if n == nil {
+				return fmt.Errorf("%s is not a struct type", name)
+			}
+			candidates = append(candidates, n)
+		}
+	}
+
+	for len(candidates) > 0 {
+		n := candidates[0]
+		candidates = candidates[1:]
+		if g.selected[n] {
+			continue
+		}
+
+		g.selected[n] = true
+		g.named = append(g.named, n)
+		st := n.Underlying().(*types.Struct)
+		for i := 0; i < st.NumFields(); i++ {
+			for _, used := range g.usedStructs(st.Field(i).Type(), make(map[types.Type]bool)) {
+				if g.tagged(used) {
+					candidates = append(candidates, used)
+				}
+			}
+		}
+	}
+
+	sort.Slice(g.named, func(i, j int) bool {
+		return g.named[i].Obj().Pos() < g.named[j].Obj().Pos()
+	})
+	return nil
+}
+
+// localStruct returns typ if it is a non generic struct type declared in the package.
+func (g *generator) localStruct(typ types.Type) *types.Named {
+	n, ok := typ.(*types.Named)
+	if !ok || n.Obj().Pkg() != g.pkg || n.TypeParams().Len() > 0 || n.Obj().IsAlias() {
+		return nil
+	}
+
+	if _, ok = n.Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	return n
+}
+
+// usedStructs returns the struct types of the package which values of typ may contain.
+func (g *generator) usedStructs(typ types.Type, seen map[types.Type]bool) (used []*types.Named) {
+	if seen[typ] {
+		return nil
+	}
+
+	seen[typ] = true
+	if n := g.localStruct(typ); n != nil {
+		return []*types.Named{n}
+	}
+
+	switch u := typ.Underlying().(type) {
+	case *types.Pointer:
+		return g.usedStructs(u.Elem(), seen)
+	case *types.Slice:
+		return g.usedStructs(u.Elem(), seen)
+	case *types.Array:
+		return g.usedStructs(u.Elem(), seen)
+	case *types.Map:
+		return append(g.usedStructs(u.Key(), seen), g.usedStructs(u.Elem(), seen)...)
+	}
+	return nil
+}
+
+// tagged reports whether the fields of the struct type typ, or of its nested struct types,
+// have any of the tags of the generator.
+func (g *generator) tagged(typ types.Type) bool {
+	for _, set := range g.sets {
+		if hasTags(typ, set.name, make(map[types.Type]bool)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve determines the types which cannot be generated for each tag set.
+// As types may use each other, this is repeated until nothing changes.
+func (g *generator) resolve() {
+	for changed := true; changed; {
+		changed = false
+		for _, set := range g.sets {
+			for _, n := range g.named {
+				if g.unsupported[set][n] != nil {
+					continue
+				}
+
+				e := &emitter{g: g, set: set, nonNil: make(map[string]bool)}
+				if _, err := e.structBody(n); err != nil {
+					g.unsupported[set][n] = err
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// file returns the formatted source code of the generated file.
+func (g *generator) file() ([]byte, error) {
+	var body bytes.Buffer
+	for _, n := range g.named {
+		var cases []string
+		var methods []string
+		for _, set := range g.sets {
+			if g.unsupported[set][n] != nil {
+				continue
+			}
+
+			e := &emitter{g: g, set: set, nonNil: make(map[string]bool)}
+			code, err := e.structBody(n)
+			if err != nil {
+				return nil, err
+			}
+
+			cases = append(cases, fmt.Sprintf("case %q:\nreturn true, v.%s(ctx)\n", set.name, e.method()))
+			methods = append(methods, fmt.Sprintf("\nfunc (v *%s) %s(ctx context.Context) error {\n%sreturn nil\n}\n", n.Obj().Name(), e.method(), code))
+		}
+
+		if len(cases) == 0 {
+			continue
+		}
+
+		g.imports["context"] = "context"
+		fmt.Fprintf(&body, "\n// MoldTag implements modifier.Generated.\nfunc (v *%s) MoldTag(ctx context.Context, tagName string) (bool, error) {\nswitch tagName {\n%s}\nreturn false, nil\n}\n", n.Obj().Name(), strings.Join(cases, ""))
+		body.WriteString(strings.Join(methods, ""))
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by moldgen; DO NOT EDIT.\n\npackage %s\n", g.pkg.Name())
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Slice(paths, func(i, j int) bool {
+			if isStd(paths[i]) != isStd(paths[j]) {
+				return isStd(paths[i])
+			}
+			return paths[i] < paths[j]
+		})
+
+		buf.WriteString("\nimport (\n")
+		for i, path := range paths {
+			// the standard library is imported first
+			if i > 0 && isStd(paths[i-1]) != isStd(path) {
+				buf.WriteString("\n")
+			}
+			fmt.Fprintf(&buf, "%q\n", path)
+		}
+		buf.WriteString(")\n")
+	}
+
+	if len(g.vars) > 0 {
+		names := make([]string, 0, len(g.vars))
+		for name := range g.vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		buf.WriteString("\nvar (\n")
+		for _, name := range names {
+			fmt.Fprintf(&buf, "%s = %s\n", name, g.vars[name])
+		}
+		buf.WriteString(")\n")
+	}
+
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+// qualifier qualifies the types of other packages with their name and records the import.
+func (g *generator) qualifier(pkg *types.Package) string {
+	if pkg == g.pkg {
+		return ""
+	}
+
+	g.imports[pkg.Path()] = pkg.Name()
+	return pkg.Name()
+}
+
+// typeString returns the name of typ within the generated file.
+func (g *generator) typeString(typ types.Type) string {
+	return types.TypeString(typ, g.qualifier)
+}
+
+// method returns the name of the generated method applying the tags of the set.
+func (e *emitter) method() string {
+	return "moldgen" + e.set.ident
+}
+
+// structBody returns the code applying the tags of the set to the fields of n.
+func (e *emitter) structBody(n *types.Named) (string, error) {
+	var b strings.Builder
+	st := n.Underlying().(*types.Struct)
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Embedded() && !f.Exported() {
+			continue
+		}
+
+		tag := reflect.StructTag(st.Tag(i)).Get(e.set.name)
+		if tag == "-" {
+			continue
+		}
+
+		if strings.Contains(tag, "'") {
+			return "", fmt.Errorf("field %s: quoted param in %q", f.Name(), tag)
+		}
+
+		var tags []string
+		if len(tag) > 0 {
+			tags = strings.Split(tag, ",")
+		}
+
+		code, err := e.value("v."+f.Name(), f.Type(), tags)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", f.Name(), err)
+		}
+
+		if len(code) > 0 {
+			fmt.Fprintf(&b, "// %s\n%s", f.Name(), code)
+		}
+	}
+	return b.String(), nil
+}
+
+// value returns the code applying tags to the value of type typ at the addressable expression x,
+// followed by the transformation of its fields if it is a struct.
+func (e *emitter) value(x string, typ types.Type, tags []string) (string, error) {
+	defer func(nonNil bool) { e.nonNil[x] = nonNil }(e.nonNil[x])
+	var b strings.Builder
+	for i, tg := range tags {
+		switch tg {
+		case "omitempty", "omitnil":
+			cond, err := e.present(x, typ, tg == "omitnil")
+			if err != nil {
+				return "", err
+			}
+
+			if strings.HasPrefix(cond, x+" != nil") {
+				e.nonNil[x] = true
+			}
+
+			rest, err := e.value(x, typ, tags[i+1:])
+			if err != nil {
+				return "", err
+			}
+
+			if len(rest) > 0 && len(cond) > 0 {
+				rest = fmt.Sprintf("if %s {\n%s}\n", cond, rest)
+			}
+			b.WriteString(rest)
+			return b.String(), nil
+		case "dive":
+			code, err := e.dive(x, typ, tags[i+1:])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(code)
+			return b.String(), nil
+		}
+
+		code, err := e.tag(x, typ, tg)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(code)
+	}
+
+	code, err := e.traverse(x, typ)
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(code)
+	return b.String(), nil
+}
+
+// present returns the condition under which a value is neither empty nor nil,
+// empty if it always holds.
+func (e *emitter) present(x string, typ types.Type, nilOnly bool) (string, error) {
+	ptr, elem, err := deref(typ)
+	if err != nil {
+		return "", err
+	}
+
+	if nilOnly {
+		switch elem.Underlying().(type) {
+		case *types.Interface:
+			return "", errors.New("interface field")
+		}
+
+		if ptr {
+			if e.nonNil[x] {
+				return "", nil
+			}
+			return x + " != nil", nil
+		}
+
+		switch elem.Underlying().(type) {
+		case *types.Map, *types.Slice, *types.Chan, *types.Signature:
+			return x + " != nil", nil
+		}
+		return "", nil
+	}
+
+	if b, ok := elem.Underlying().(*types.Basic); ok {
+		zero, ok := zeroValue(b)
+		if !ok {
+			return "", fmt.Errorf("omitempty on %s", elem)
+		}
+
+		cond := x
+		if ptr {
+			cond = "*" + x
+		}
+
+		if b.Info()&types.IsBoolean != 0 {
+			if ptr {
+				return fmt.Sprintf("%s != nil && %s", x, cond), nil
+			}
+			return cond, nil
+		}
+
+		cond = fmt.Sprintf("%s != %s", cond, zero)
+		if ptr {
+			cond = fmt.Sprintf("%s != nil && %s", x, cond)
+		}
+		return cond, nil
+	}
+
+	if !ptr {
+		switch elem.Underlying().(type) {
+		case *types.Map, *types.Slice, *types.Chan, *types.Signature:
+			return x + " != nil", nil
+		}
+	}
+	return "", fmt.Errorf("omitempty on %s", typ)
+}
+
+// dive returns the code applying tags to the elements of the collection at x.
+func (e *emitter) dive(x string, typ types.Type, tags []string) (string, error) {
+	e.depth++
+	defer func() { e.depth-- }()
+
+	switch u := typ.Underlying().(type) {
+	case *types.Slice, *types.Array:
+		elem := u.(interface{ Elem() types.Type }).Elem()
+		i := fmt.Sprintf("i%d", e.depth)
+		code, err := e.value(fmt.Sprintf("%s[%s]", x, i), elem, tags)
+		if err != nil || len(code) == 0 {
+			return "", err
+		}
+		return fmt.Sprintf("for %s := range %s {\n%s}\n", i, x, code), nil
+	case *types.Map:
+		for _, tg := range tags {
+			if tg == "keys" {
+				return "", errors.New("keys tag")
+			}
+		}
+
+		k, v := fmt.Sprintf("k%d", e.depth), fmt.Sprintf("e%d", e.depth)
+		code, err := e.value(v, u.Elem(), tags)
+		if err != nil || len(code) == 0 {
+			return "", err
+		}
+		return fmt.Sprintf("for %s, %s := range %s {\n%s%s[%s] = %s\n}\n", k, v, x, code, x, k, v), nil
+	}
+	return "", fmt.Errorf("dive on %s", typ)
+}
+
+// tag returns the code applying a single tag to the value at x.
+func (e *emitter) tag(x string, typ types.Type, tg string) (string, error) {
+	if strings.Contains(tg, "|") {
+		return "", fmt.Errorf("OR group %q", tg)
+	}
+
+	name, param, _ := strings.Cut(tg, "=")
+	switch name {
+	case "if", "unless":
+		return "", fmt.Errorf("conditional tag %q", tg)
+	case "substr":
+		return "", errors.New("substr tag")
+	case "keys", "endkeys":
+		return "", fmt.Errorf("%s tag", name)
+	}
+
+	param = strings.ReplaceAll(strings.ReplaceAll(param, "0x2C", ","), "0x7C", "|")
+	ptr, elem, err := deref(typ)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := elem.Underlying().(*types.Interface); ok {
+		return "", errors.New("interface field")
+	}
+
+	if e.set.funcs[name] {
+		return e.stringFunc(x, ptr, elem, name, param), nil
+	}
+
+	if !e.set.builtins {
+		return "", fmt.Errorf("unknown tag %q", name)
+	}
+
+	b, _ := elem.Underlying().(*types.Basic)
+	y := x
+	if ptr {
+		y = "*" + x
+	}
+
+	switch name {
+	case "set", "default":
+		if b == nil {
+			return "", fmt.Errorf("%s on %s", name, typ)
+		}
+
+		// named types may have a registered parser or implement an unmarshaler
+		if _, named := elem.(*types.Named); named && !isDuration(elem) {
+			return "", fmt.Errorf("%s on named type %s", name, typ)
+		}
+
+		lit, ok := literal(elem, b, param)
+		if !ok {
+			return "", fmt.Errorf("%s parameter %q for %s", name, param, typ)
+		}
+
+		var code string
+		if ptr && !e.nonNil[x] {
+			code = fmt.Sprintf("if %s == nil {\n%s = new(%s)\n}\n", x, x, e.g.typeString(elem))
+			e.nonNil[x] = true
+		}
+
+		if name == "default" {
+			cond := "!" + y
+			if zero, _ := zeroValue(b); zero != "false" {
+				cond = fmt.Sprintf("%s == %s", y, zero)
+			}
+			return code + fmt.Sprintf("if %s {\n%s = %s\n}\n", cond, y, lit), nil
+		}
+		return code + fmt.Sprintf("%s = %s\n", y, lit), nil
+	case "empty":
+		if b == nil {
+			if ptr {
+				return "", fmt.Errorf("empty on %s", typ)
+			}
+
+			switch elem.Underlying().(type) {
+			case *types.Map, *types.Slice:
+				return fmt.Sprintf("%s = nil\n", x), nil
+			}
+			return "", fmt.Errorf("empty on %s", typ)
+		}
+
+		zero, ok := zeroValue(b)
+		if !ok {
+			return "", fmt.Errorf("empty on %s", typ)
+		}
+
+		if ptr && !e.nonNil[x] {
+			return fmt.Sprintf("if %s != nil {\n%s = %s\n}\n", x, y, zero), nil
+		}
+		return fmt.Sprintf("%s = %s\n", y, zero), nil
+	}
+	return "", fmt.Errorf("unknown tag %q", name)
+}
+
+// stringFunc returns the code calling the string function of tag name,
+// which does nothing for values other than strings.
+func (e *emitter) stringFunc(x string, ptr bool, elem types.Type, name, param string) string {
+	if b, ok := elem.Underlying().(*types.Basic); !ok || b.Info()&types.IsString == 0 {
+		return ""
+	}
+
+	fn := "moldgen" + e.set.ident + identifier(name)
+	e.g.imports[e.set.pkgPath] = path.Base(e.set.pkgPath)
+	e.g.vars[fn] = fmt.Sprintf("%s.StringFuncs()[%q]", path.Base(e.set.pkgPath), name)
+
+	y := x
+	if ptr {
+		y = "*" + x
+	}
+
+	arg, s := y, "s"
+	if typ := e.g.typeString(elem); typ != "string" {
+		arg, s = fmt.Sprintf("string(%s)", y), fmt.Sprintf("%s(s)", typ)
+	}
+
+	code := fmt.Sprintf("if s, err := %s(ctx, %s, %q); err != nil {\nreturn err\n} else {\n%s = %s\n}\n", fn, arg, param, y, s)
+	if ptr && !e.nonNil[x] {
+		code = fmt.Sprintf("if %s != nil {\n%s}\n", x, code)
+	}
+	return code
+}
+
+// traverse returns the code transforming the fields of the struct at x.
+func (e *emitter) traverse(x string, typ types.Type) (string, error) {
+	ptr, elem, err := deref(typ)
+	if err != nil {
+		if hasTags(typ, e.set.name, make(map[types.Type]bool)) {
+			return "", err
+		}
+		return "", nil
+	}
+
+	switch elem.Underlying().(type) {
+	case *types.Interface:
+		return "", errors.New("interface field")
+	case *types.Struct:
+	default:
+		return "", nil
+	}
+
+	if isTime(elem) || !hasTags(elem, e.set.name, make(map[types.Type]bool)) {
+		return "", nil
+	}
+
+	n := e.g.localStruct(elem)
+	if n == nil || !e.g.selected[n] {
+		return "", fmt.Errorf("%s is not generated", elem)
+	}
+
+	if e.g.unsupported[e.set][n] != nil {
+		return "", fmt.Errorf("%s is transformed with reflection", n.Obj().Name())
+	}
+
+	code := fmt.Sprintf("if err := %s.%s(ctx); err != nil {\nreturn err\n}\n", x, e.method())
+	if ptr && !e.nonNil[x] {
+		code = fmt.Sprintf("if %s != nil {\n%s}\n", x, code)
+	}
+	return code, nil
+}
+
+// deref returns whether typ is a pointer along with the type it points to.
+func deref(typ types.Type) (bool, types.Type, error) {
+	p, ok := typ.Underlying().(*types.Pointer)
+	if !ok {
+		return false, typ, nil
+	}
+
+	if _, ok = p.Elem().Underlying().(*types.Pointer); ok {
+		return false, nil, fmt.Errorf("pointer to pointer %s", typ)
+	}
+	return true, p.Elem(), nil
+}
+
+// hasTags reports whether the struct type typ, or a struct type it contains,
+// has fields with the tag name or fields of interface types.
+func hasTags(typ types.Type, name string, seen map[types.Type]bool) bool {
+	for {
+		p, ok := typ.Underlying().(*types.Pointer)
+		if !ok {
+			break
+		}
+		typ = p.Elem()
+	}
+
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+
+	switch u := typ.Underlying().(type) {
+	case *types.Interface:
+		return true
+	case *types.Struct:
+		if isTime(typ) {
+			return false
+		}
+
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if !f.Embedded() && !f.Exported() {
+				continue
+			}
+
+			tag := reflect.StructTag(u.Tag(i)).Get(name)
+			if tag == "-" {
+				continue
+			}
+
+			if len(tag) > 0 || hasTags(f.Type(), name, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isStd reports whether path is the import path of a package of the standard library.
+func isStd(path string) bool {
+	return !strings.Contains(strings.Split(path, "/")[0], ".")
+}
+
+func isTime(typ types.Type) bool {
+	n, ok := typ.(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == "time" && n.Obj().Name() == "Time"
+}
+
+func isDuration(typ types.Type) bool {
+	n, ok := typ.(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == "time" && n.Obj().Name() == "Duration"
+}
+
+// zeroValue returns the literal of the zero value of a basic type.
+func zeroValue(b *types.Basic) (string, bool) {
+	switch info := b.Info(); {
+	case info&types.IsString != 0:
+		return `""`, true
+	case info&types.IsBoolean != 0:
+		return "false", true
+	case info&(types.IsInteger|types.IsFloat) != 0 && b.Kind() != types.Uintptr:
+		return "0", true
+	}
+	return "", false
+}
+
+// literal returns the literal of the value the set modifier assigns to a value of type typ
+// for param and false if the value cannot be represented by a constant.
+func literal(typ types.Type, b *types.Basic, param string) (string, bool) {
+	switch info := b.Info(); {
+	case info&types.IsString != 0:
+		return strconv.Quote(param), true
+	case info&types.IsBoolean != 0:
+		v, err := strconv.ParseBool(param)
+		if err != nil {
+			return "", false
+		}
+		return strconv.FormatBool(v), true
+	case isDuration(typ):
+		d, err := time.ParseDuration(param)
+		if err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("%d // %s", int64(d), param), true
+	case info&types.IsInteger != 0:
+		v, err := strconv.Atoi(param)
+		if err != nil {
+			return "", false
+		}
+
+		lo, hi := intRange(b.Kind())
+		if lo > hi || float64(v) < lo || float64(v) > hi {
+			return "", false
+		}
+		return strconv.Itoa(v), true
+	case info&types.IsFloat != 0:
+		v, err := strconv.ParseFloat(param, 64)
+		if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+			return "", false
+		}
+
+		if b.Kind() == types.Float32 && math.Abs(v) > math.MaxFloat32 {
+			return "", false
+		}
+
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	}
+	return "", false
+}
+
+// intRange returns the range of the values of an integer kind, an empty range if unsupported.
+func intRange(kind types.BasicKind) (float64, float64) {
+	switch kind {
+	case types.Int8:
+		return math.MinInt8, math.MaxInt8
+	case types.Int16:
+		return math.MinInt16, math.MaxInt16
+	case types.Int32:
+		return math.MinInt32, math.MaxInt32
+	case types.Int, types.Int64:
+		return math.MinInt64, math.MaxInt64
+	case types.Uint8:
+		return 0, math.MaxUint8
+	case types.Uint16:
+		return 0, math.MaxUint16
+	case types.Uint32:
+		return 0, math.MaxUint32
+	case types.Uint, types.Uint64:
+		return 0, math.MaxInt64
+	}
+	return 1, 0
+}
+
+// identifier returns s in camel case with the characters which are invalid in identifiers removed.
+func identifier(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			if upper {
+				b.WriteString(strings.ToUpper(string(r)))
+			} else {
+				b.WriteRune(r)
+			}
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+// Package example contains types for which code is generated by moldgen,
+// the generated code is compared to the reflection based transformation by its tests.
+package example
+
+//go:generate go run github.com/pchchv/modifier/cmd/moldgen
+
+import (
+	"time"
+)
+
+// Email is a named string type.
+type Email string
+
+// User is transformed by generated code.
+type User struct {
+	Name      string            `mod:"trim,title" scrub:"name"`
+	Email     Email             `mod:"trim,lcase" scrub:"emails"`
+	Nickname  *string           `mod:"omitnil,trim,ucfirst"`
+	Role      string            `mod:"default=member"`
+	Age       int8              `mod:"default=18"`
+	Score     float32           `mod:"set=1.5"`
+	Active    *bool             `mod:"default=true"`
+	Timeout   time.Duration     `mod:"default=1m30s"`
+	Password  string            `mod:"empty" scrub:"text"`
+	Tags      []string          `mod:"omitempty,dive,trim,lcase"`
+	Labels    map[string]string `mod:"dive,trim"`
+	Codes     [2]string         `mod:"dive,ucase"`
+	Address   Address
+	Previous  *Address
+	Addresses []Address `mod:"dive"`
+	Created   time.Time
+	Ignored   string `mod:"-"`
+}
+
+// Address is used by User.
+type Address struct {
+	Street string `mod:"trim,name"`
+	City   string `mod:"omitempty,trim,tsuffix=0x2C,ucfirst"`
+	Zip    string `mod:"strip_num"`
+}
+
+// Fallback uses tags which cannot be generated and is transformed with reflection.
+type Fallback struct {
+	Name  string `mod:"trim|lcase"`
+	Value string `mod:"trim"`
+}
+
+// Wrapper uses Fallback and so is transformed with reflection as well.
+type Wrapper struct {
+	Fallback Fallback
+	Name     string `mod:"trim"`
+}
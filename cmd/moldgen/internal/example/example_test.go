@@ -0,0 +1,90 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/pchchv/go-assert"
+	"github.com/pchchv/modifier"
+	"github.com/pchchv/modifier/modifiers"
+	"github.com/pchchv/modifier/scrubbers"
+)
+
+func newUser() *User {
+	nickname := "  joe  "
+	return &User{
+		Name:      "  joey JOHNSON ",
+		Email:     "  Joe@Example.COM ",
+		Nickname:  &nickname,
+		Age:       42,
+		Password:  "secret",
+		Tags:      []string{" Go ", "RUST"},
+		Labels:    map[string]string{"a": " b "},
+		Codes:     [2]string{"ab", "cd"},
+		Address:   Address{Street: " 5th  avenue1 ", City: " new york, ", Zip: "NY 10001"},
+		Previous:  &Address{Street: "main street"},
+		Addresses: []Address{{City: "  boston"}, {}},
+		Created:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Ignored:   " ignored ",
+	}
+}
+
+func TestGeneratedEqualsReflection(t *testing.T) {
+	ctx := context.Background()
+	for _, newTransformer := range []func() *modifier.Transformer{modifiers.New, scrubbers.New} {
+		generated, reflected := newTransformer(), newTransformer()
+		generated.SetUseGenerated(true)
+		for _, v := range []*User{newUser(), {}} {
+			copied, err := modifier.Clone(ctx, reflected, *v)
+			Equal(t, err, nil)
+			Equal(t, generated.Struct(ctx, v), nil)
+			Equal(t, *v, copied)
+		}
+
+		w1 := &Wrapper{Fallback: Fallback{Name: " A ", Value: " B "}, Name: " C "}
+		w2 := &Wrapper{Fallback: Fallback{Name: " A ", Value: " B "}, Name: " C "}
+		Equal(t, generated.Struct(ctx, w1), nil)
+		Equal(t, reflected.Struct(ctx, w2), nil)
+		Equal(t, w1, w2)
+	}
+}
+
+func TestMoldTag(t *testing.T) {
+	ctx := context.Background()
+	handled, err := new(User).MoldTag(ctx, "mod")
+	Equal(t, handled, true)
+	Equal(t, err, nil)
+
+	handled, err = new(User).MoldTag(ctx, "other")
+	Equal(t, handled, false)
+	Equal(t, err, nil)
+
+	// OR groups are not generated
+	handled, _ = new(Fallback).MoldTag(ctx, "mod")
+	Equal(t, handled, false)
+
+	handled, _ = new(Wrapper).MoldTag(ctx, "mod")
+	Equal(t, handled, false)
+
+	handled, _ = new(Wrapper).MoldTag(ctx, "scrub")
+	Equal(t, handled, true)
+}
+
+func TestGeneratedErrors(t *testing.T) {
+	errTitle := errors.New("title failed")
+	mod := modifiers.New()
+	mod.Register("title", func(ctx context.Context, fl modifier.FieldLevel) error {
+		return errTitle
+	})
+
+	// registered transformations are used unless generated code is enabled,
+	// which always calls the built-in transformations
+	user := newUser()
+	Equal(t, errors.Is(mod.Struct(context.Background(), user), errTitle), true)
+
+	mod.SetUseGenerated(true)
+	user = newUser()
+	Equal(t, mod.Struct(context.Background(), user), nil)
+}
@@ -0,0 +1,247 @@
+// Code generated by moldgen; DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+
+	"github.com/pchchv/modifier/modifiers"
+	"github.com/pchchv/modifier/scrubbers"
+)
+
+var (
+	moldgenModLcase    = modifiers.StringFuncs()["lcase"]
+	moldgenModName     = modifiers.StringFuncs()["name"]
+	moldgenModStripNum = modifiers.StringFuncs()["strip_num"]
+	moldgenModTitle    = modifiers.StringFuncs()["title"]
+	moldgenModTrim     = modifiers.StringFuncs()["trim"]
+	moldgenModTsuffix  = modifiers.StringFuncs()["tsuffix"]
+	moldgenModUcase    = modifiers.StringFuncs()["ucase"]
+	moldgenModUcfirst  = modifiers.StringFuncs()["ucfirst"]
+	moldgenScrubEmails = scrubbers.StringFuncs()["emails"]
+	moldgenScrubName   = scrubbers.StringFuncs()["name"]
+	moldgenScrubText   = scrubbers.StringFuncs()["text"]
+)
+
+// MoldTag implements modifier.Generated.
+func (v *User) MoldTag(ctx context.Context, tagName string) (bool, error) {
+	switch tagName {
+	case "mod":
+		return true, v.moldgenMod(ctx)
+	case "scrub":
+		return true, v.moldgenScrub(ctx)
+	}
+	return false, nil
+}
+
+func (v *User) moldgenMod(ctx context.Context) error {
+	// Name
+	if s, err := moldgenModTrim(ctx, v.Name, ""); err != nil {
+		return err
+	} else {
+		v.Name = s
+	}
+	if s, err := moldgenModTitle(ctx, v.Name, ""); err != nil {
+		return err
+	} else {
+		v.Name = s
+	}
+	// Email
+	if s, err := moldgenModTrim(ctx, string(v.Email), ""); err != nil {
+		return err
+	} else {
+		v.Email = Email(s)
+	}
+	if s, err := moldgenModLcase(ctx, string(v.Email), ""); err != nil {
+		return err
+	} else {
+		v.Email = Email(s)
+	}
+	// Nickname
+	if v.Nickname != nil {
+		if s, err := moldgenModTrim(ctx, *v.Nickname, ""); err != nil {
+			return err
+		} else {
+			*v.Nickname = s
+		}
+		if s, err := moldgenModUcfirst(ctx, *v.Nickname, ""); err != nil {
+			return err
+		} else {
+			*v.Nickname = s
+		}
+	}
+	// Role
+	if v.Role == "" {
+		v.Role = "member"
+	}
+	// Age
+	if v.Age == 0 {
+		v.Age = 18
+	}
+	// Score
+	v.Score = 1.5
+	// Active
+	if v.Active == nil {
+		v.Active = new(bool)
+	}
+	if !*v.Active {
+		*v.Active = true
+	}
+	// Timeout
+	if v.Timeout == 0 {
+		v.Timeout = 90000000000 // 1m30s
+	}
+	// Password
+	v.Password = ""
+	// Tags
+	if v.Tags != nil {
+		for i1 := range v.Tags {
+			if s, err := moldgenModTrim(ctx, v.Tags[i1], ""); err != nil {
+				return err
+			} else {
+				v.Tags[i1] = s
+			}
+			if s, err := moldgenModLcase(ctx, v.Tags[i1], ""); err != nil {
+				return err
+			} else {
+				v.Tags[i1] = s
+			}
+		}
+	}
+	// Labels
+	for k1, e1 := range v.Labels {
+		if s, err := moldgenModTrim(ctx, e1, ""); err != nil {
+			return err
+		} else {
+			e1 = s
+		}
+		v.Labels[k1] = e1
+	}
+	// Codes
+	for i1 := range v.Codes {
+		if s, err := moldgenModUcase(ctx, v.Codes[i1], ""); err != nil {
+			return err
+		} else {
+			v.Codes[i1] = s
+		}
+	}
+	// Address
+	if err := v.Address.moldgenMod(ctx); err != nil {
+		return err
+	}
+	// Previous
+	if v.Previous != nil {
+		if err := v.Previous.moldgenMod(ctx); err != nil {
+			return err
+		}
+	}
+	// Addresses
+	for i1 := range v.Addresses {
+		if err := v.Addresses[i1].moldgenMod(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *User) moldgenScrub(ctx context.Context) error {
+	// Name
+	if s, err := moldgenScrubName(ctx, v.Name, ""); err != nil {
+		return err
+	} else {
+		v.Name = s
+	}
+	// Email
+	if s, err := moldgenScrubEmails(ctx, string(v.Email), ""); err != nil {
+		return err
+	} else {
+		v.Email = Email(s)
+	}
+	// Password
+	if s, err := moldgenScrubText(ctx, v.Password, ""); err != nil {
+		return err
+	} else {
+		v.Password = s
+	}
+	return nil
+}
+
+// MoldTag implements modifier.Generated.
+func (v *Address) MoldTag(ctx context.Context, tagName string) (bool, error) {
+	switch tagName {
+	case "mod":
+		return true, v.moldgenMod(ctx)
+	case "scrub":
+		return true, v.moldgenScrub(ctx)
+	}
+	return false, nil
+}
+
+func (v *Address) moldgenMod(ctx context.Context) error {
+	// Street
+	if s, err := moldgenModTrim(ctx, v.Street, ""); err != nil {
+		return err
+	} else {
+		v.Street = s
+	}
+	if s, err := moldgenModName(ctx, v.Street, ""); err != nil {
+		return err
+	} else {
+		v.Street = s
+	}
+	// City
+	if v.City != "" {
+		if s, err := moldgenModTrim(ctx, v.City, ""); err != nil {
+			return err
+		} else {
+			v.City = s
+		}
+		if s, err := moldgenModTsuffix(ctx, v.City, ","); err != nil {
+			return err
+		} else {
+			v.City = s
+		}
+		if s, err := moldgenModUcfirst(ctx, v.City, ""); err != nil {
+			return err
+		} else {
+			v.City = s
+		}
+	}
+	// Zip
+	if s, err := moldgenModStripNum(ctx, v.Zip, ""); err != nil {
+		return err
+	} else {
+		v.Zip = s
+	}
+	return nil
+}
+
+func (v *Address) moldgenScrub(ctx context.Context) error {
+	return nil
+}
+
+// MoldTag implements modifier.Generated.
+func (v *Fallback) MoldTag(ctx context.Context, tagName string) (bool, error) {
+	switch tagName {
+	case "scrub":
+		return true, v.moldgenScrub(ctx)
+	}
+	return false, nil
+}
+
+func (v *Fallback) moldgenScrub(ctx context.Context) error {
+	return nil
+}
+
+// MoldTag implements modifier.Generated.
+func (v *Wrapper) MoldTag(ctx context.Context, tagName string) (bool, error) {
+	switch tagName {
+	case "scrub":
+		return true, v.moldgenScrub(ctx)
+	}
+	return false, nil
+}
+
+func (v *Wrapper) moldgenScrub(ctx context.Context) error {
+	return nil
+}
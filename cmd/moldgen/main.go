@@ -0,0 +1,77 @@
+// Command moldgen generates code which applies the transformations of struct tags without reflection.
+//
+// For every struct type of a package it emits a MoldTag method implementing modifier.Generated,
+// which is used by a modifier.Transformer instead of the reflection based transformation
+// once enabled with SetUseGenerated(true).
+// The built-in transformations of the modifiers and scrubbers packages are supported,
+// types using tags which cannot be generated, e. g. unknown tags, aliases, OR groups, quoted params, conditional tags
+// or set and default on named types other than time.Duration,
+// are reported and fall back to reflection.
+//
+// Usage:
+//
+//	//go:generate go run github.com/pchchv/modifier/cmd/moldgen [flags] [package]
+//
+// Flags:
+//
+//	-mod string      tag name of the modifiers package transformations, empty to ignore (default "mod")
+//	-scrub string    tag name of the scrubbers package transformations, empty to ignore (default "scrub")
+//	-type string     comma separated list of the types to generate, default all tagged struct types
+//	-output string   output file name, relative to the package directory (default "mold_gen.go")
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	var cfg config
+	var typeNames string
+	flag.StringVar(&cfg.modTag, "mod", "mod", "tag name of the modifiers package transformations, empty to ignore")
+	flag.StringVar(&cfg.scrubTag, "scrub", "scrub", "tag name of the scrubbers package transformations, empty to ignore")
+	flag.StringVar(&typeNames, "type", "", "comma separated list of the types to generate, default all tagged struct types")
+	flag.StringVar(&cfg.output, "output", "mold_gen.go", "output file name, relative to the package directory")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: moldgen [flags] [package]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if len(typeNames) > 0 {
+		cfg.types = strings.Split(typeNames, ",")
+	}
+
+	pattern := "."
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		pattern = flag.Arg(0)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	res, err := generate(cfg, pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "moldgen: %s\n", err)
+		os.Exit(1)
+	}
+
+	for _, msg := range res.fallbacks {
+		fmt.Fprintf(os.Stderr, "moldgen: %s\n", msg)
+	}
+
+	output := cfg.output
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(res.dir, output)
+	}
+
+	if err = os.WriteFile(output, res.src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "moldgen: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/pchchv/go-assert"
+)
+
+func TestGenerate(t *testing.T) {
+	res, err := generate(config{modTag: "mod", scrubTag: "scrub", output: "mold_gen.go"}, "./internal/example")
+	Equal(t, err, nil)
+	Equal(t, res.fallbacks, []string{
+		`Fallback: mod tags are transformed with reflection: field Name: OR group "trim|lcase"`,
+		"Wrapper: mod tags are transformed with reflection: field Fallback: Fallback is transformed with reflection",
+	})
+
+	// run go generate ./cmd/moldgen/internal/example when the generated code changes
+	golden, err := os.ReadFile(filepath.Join(res.dir, "mold_gen.go"))
+	Equal(t, err, nil)
+	Equal(t, string(res.src), string(golden))
+
+	res, err = generate(config{modTag: "mod", types: []string{"Address"}, output: "mold_gen.go"}, "./internal/example")
+	Equal(t, err, nil)
+	Equal(t, len(res.fallbacks), 0)
+	NotEqual(t, string(res.src), string(golden))
+
+	_, err = generate(config{modTag: "mod", types: []string{"Email"}, output: "mold_gen.go"}, "./internal/example")
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "Email is not a struct type")
+
+	_, err = generate(config{modTag: "mod", types: []string{"Missing"}, output: "mold_gen.go"}, "./internal/example")
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "type Missing not found in package github.com/pchchv/modifier/cmd/moldgen/internal/example")
+}
+
+func TestIdentifier(t *testing.T) {
+	Equal(t, identifier("mod"), "Mod")
+	Equal(t, identifier("strip_alpha_unicode"), "StripAlphaUnicode")
+	Equal(t, identifier("x-mod.v2"), "XModV2")
+}
@@ -0,0 +1,16 @@
+// Command moldvet checks the mold, mod and scrub tags of struct fields,
+// see the moldvet package for the checks and flags.
+//
+// Usage:
+//
+//	moldvet [-extra=e164,mod:phone] [packages]
+package main
+
+import (
+	"github.com/pchchv/modifier/moldvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(moldvet.Analyzer)
+}
@@ -0,0 +1,60 @@
+package modifier
+
+import (
+	"context"
+
+	"golang.org/x/text/language"
+)
+
+type parallelismKey struct{}
+
+// WithParallelism returns a copy of ctx which makes transformations using it
+// process the elements of slices, arrays and maps, which are being dived into,
+// using up to n goroutines in total.
+// Values reached from different elements must not be shared,
+// e. g. pointers to the same struct, as they would be modified concurrently.
+// The registered transformations and the FilterFunc passed to StructFiltered
+// are called concurrently as well and must be safe for concurrent use.
+// Errors are reported as if the elements were processed in order,
+// but elements after the one that failed may have been transformed already.
+func WithParallelism(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, parallelismKey{}, n)
+}
+
+// parallelism returns the number of goroutines set using WithParallelism.
+func parallelism(ctx context.Context) int {
+	n, _ := ctx.Value(parallelismKey{}).(int)
+	return n
+}
+
+type groupsKey struct{}
+
+// WithGroups returns a copy of ctx which makes transformations using it apply the tags of the given groups
+// in addition to the tags of the fields, in the order of the groups.
+// The tags of a group are named by the tag name and the group separated by a dot
+// e. g. `mod:"trim" mod.create:"default=pending"` for the group create,
+// a field with a group's tag of "-" is ignored while that group is used.
+func WithGroups(ctx context.Context, groups ...string) context.Context {
+	return context.WithValue(ctx, groupsKey{}, groups)
+}
+
+// groups returns the groups set using WithGroups.
+func groups(ctx context.Context) []string {
+	g, _ := ctx.Value(groupsKey{}).([]string)
+	return g
+}
+
+type languageKey struct{}
+
+// WithLanguage returns a copy of ctx which makes locale aware transformations using it,
+// e. g. the case transformations of the modifiers package, use the rules of the language tag
+// e. g. language.Turkish to map i to İ.
+func WithLanguage(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, languageKey{}, tag)
+}
+
+// Language returns the language tag set using WithLanguage and whether one was set.
+func Language(ctx context.Context) (language.Tag, bool) {
+	tag, ok := ctx.Value(languageKey{}).(language.Tag)
+	return tag, ok
+}
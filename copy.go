@@ -0,0 +1,191 @@
+package modifier
+
+import (
+	"context"
+	"reflect"
+)
+
+// visitKey identifies a pointer, map or slice already copied by deepCopy.
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int // length of a slice, as slices of an array may start at the same element
+}
+
+// StructCopy applies transformations against a deep copy of the provided struct,
+// or pointer to struct, leaving the original untouched.
+// The transformed copy is returned with the same type as v.
+//
+// Pointers, interfaces, slices, arrays and maps reachable through
+// the fields transformations are applied to are copied,
+// unexported fields are shared with the original.
+func (t *Transformer) StructCopy(ctx context.Context, v interface{}) (interface{}, error) {
+	return t.structCopy(ctx, v, "StructCopy", func(cp interface{}) error {
+		return t.Struct(ctx, cp)
+	})
+}
+
+// structCopy calls transform with a pointer to a deep copy of v and returns the copy.
+func (t *Transformer) structCopy(ctx context.Context, v interface{}, fn string, transform func(cp interface{}) error) (interface{}, error) {
+	val := reflect.ValueOf(v)
+	if !val.IsValid() || (val.Kind() == reflect.Ptr && val.IsNil()) {
+		return nil, &ErrInvalidTransformValue{typ: reflect.TypeOf(v), fn: fn}
+	}
+
+	cp, err := t.deepCopy(val, make(map[visitKey]reflect.Value))
+	if err != nil {
+		return nil, err
+	}
+
+	if cp.Kind() == reflect.Ptr {
+		if err = transform(cp.Interface()); err != nil {
+			return nil, err
+		}
+		return cp.Interface(), nil
+	}
+
+	ptr := reflect.New(cp.Type())
+	ptr.Elem().Set(cp)
+	if err = transform(ptr.Interface()); err != nil {
+		return nil, err
+	}
+
+	return ptr.Elem().Interface(), nil
+}
+
+// Clone applies the transformations of t against a deep copy of v and returns it,
+// v must be a struct or pointer to struct. See Transformer.StructCopy.
+func Clone[T any](ctx context.Context, t *Transformer, v T) (T, error) {
+	cp, err := t.StructCopy(ctx, v)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return cp.(T), nil
+}
+
+// deepCopy returns a copy of src which shares no
+// pointers, slices or maps with it along the fields being transformed.
+// Values referred to multiple times are copied once, which also ends cycles.
+func (t *Transformer) deepCopy(src reflect.Value, visited map[visitKey]reflect.Value) (reflect.Value, error) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return src, nil
+		}
+
+		key := visitKey{ptr: src.Pointer(), typ: src.Type()}
+		if dst, ok := visited[key]; ok {
+			return dst, nil
+		}
+
+		dst := reflect.New(src.Type().Elem())
+		visited[key] = dst
+		inner, err := t.deepCopy(src.Elem(), visited)
+		if err != nil {
+			return src, err
+		}
+		dst.Elem().Set(inner)
+		return dst, nil
+	case reflect.Interface:
+		if src.IsNil() {
+			return src, nil
+		}
+
+		inner, err := t.deepCopy(src.Elem(), visited)
+		if err != nil {
+			return src, err
+		}
+		dst := reflect.New(src.Type()).Elem()
+		dst.Set(inner)
+		return dst, nil
+	case reflect.Slice:
+		if src.IsNil() {
+			return src, nil
+		}
+
+		var key visitKey
+		if src.Len() > 0 {
+			key = visitKey{ptr: src.Pointer(), typ: src.Type(), len: src.Len()}
+			if dst, ok := visited[key]; ok {
+				return dst, nil
+			}
+		}
+
+		dst := reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
+		if src.Len() > 0 {
+			visited[key] = dst
+		}
+		for i := 0; i < src.Len(); i++ {
+			elem, err := t.deepCopy(src.Index(i), visited)
+			if err != nil {
+				return src, err
+			}
+			dst.Index(i).Set(elem)
+		}
+		return dst, nil
+	case reflect.Array:
+		dst := reflect.New(src.Type()).Elem()
+		for i := 0; i < src.Len(); i++ {
+			elem, err := t.deepCopy(src.Index(i), visited)
+			if err != nil {
+				return src, err
+			}
+			dst.Index(i).Set(elem)
+		}
+		return dst, nil
+	case reflect.Map:
+		if src.IsNil() {
+			return src, nil
+		}
+
+		key := visitKey{ptr: src.Pointer(), typ: src.Type()}
+		if dst, ok := visited[key]; ok {
+			return dst, nil
+		}
+
+		dst := reflect.MakeMapWithSize(src.Type(), src.Len())
+		visited[key] = dst
+		iter := src.MapRange()
+		for iter.Next() {
+			elem, err := t.deepCopy(iter.Value(), visited)
+			if err != nil {
+				return src, err
+			}
+			dst.SetMapIndex(iter.Key(), elem)
+		}
+		return dst, nil
+	case reflect.Struct:
+		typ := src.Type()
+		if typ == timeType {
+			return src, nil
+		}
+
+		// groups can only ignore further fields, so all fields are copied
+		cs, ok := t.cCache.Get(structKey{typ: typ})
+		if !ok {
+			var err error
+			if cs, err = t.extractStructCache(src, nil); err != nil {
+				return src, err
+			}
+		}
+
+		dst := reflect.New(typ).Elem()
+		dst.Set(src)
+		for _, f := range cs.fields {
+			fld := dst.Field(f.idx)
+			if !fld.CanSet() {
+				continue
+			}
+
+			v, err := t.deepCopy(src.Field(f.idx), visited)
+			if err != nil {
+				return src, err
+			}
+			fld.Set(v)
+		}
+		return dst, nil
+	default:
+		return src, nil
+	}
+}
@@ -14,6 +14,9 @@ var (
 	ErrInvalidKeysTag = errors.New("'" + keysTag + "' tag must be immediately preceeded by the '" + diveTag + "' tag")
 	// ErrUndefinedKeysTag describes an undefined keys tag when and endkeys tag defined.
 	ErrUndefinedKeysTag = errors.New("'" + endKeysTag + "' tag encountered without a corresponding '" + keysTag + "' tag")
+	// ErrNotApplicable can be returned by a Func to signal that it does not apply to the value,
+	// in an OR group the next alternative is tried, otherwise the value is left as is.
+	ErrNotApplicable = errors.New("transformation not applicable")
 )
 
 // ErrInvalidTag defines a bad value for a tag being used.
@@ -38,6 +41,17 @@ func (e *ErrUndefinedTag) Error() string {
 	return strings.TrimSpace(fmt.Sprintf("unregistered/undefined transformation '%s' found on field %s", e.tag, e.field))
 }
 
+// ErrAliasCycle describes an alias which refers to itself, directly or through other aliases.
+type ErrAliasCycle struct {
+	aliases []string
+	field   string
+}
+
+// Error returns the ErrAliasCycle message.
+func (e *ErrAliasCycle) Error() string {
+	return strings.TrimSpace(fmt.Sprintf("alias '%s' refers to itself through '%s' on field %s", e.aliases[len(e.aliases)-1], strings.Join(e.aliases, " -> "), e.field))
+}
+
 // An ErrInvalidTransformValue describes an invalid argument passed to Struct or Var.
 // The argument passed must be a non-nil pointer.
 type ErrInvalidTransformValue struct {
@@ -66,3 +80,193 @@ type ErrInvalidTransformation struct {
 func (e *ErrInvalidTransformation) Error() string {
 	return "mold: (nil " + e.typ.String() + ")"
 }
+
+// ErrInvalidMapRule describes a rule passed to Map which is neither tags nor nested rules,
+// or nested rules for a value which is no map[string]interface{}.
+type ErrInvalidMapRule struct {
+	ns    string
+	rule  reflect.Type
+	value reflect.Type
+}
+
+// Error returns the ErrInvalidMapRule message.
+func (e *ErrInvalidMapRule) Error() string {
+	if e.value != nil {
+		return fmt.Sprintf("mold: nested rules for '%s' of type %s instead of map[string]interface {}", e.ns, e.value)
+	}
+	return fmt.Sprintf("mold: invalid rule for '%s' of type %v instead of string or map[string]interface {}", e.ns, e.rule)
+}
+
+// ErrCanceled describes a transformation that was aborted
+// because the context passed to it was canceled or it's deadline exceeded.
+type ErrCanceled struct {
+	ns        string
+	processed int
+	err       error
+}
+
+// Namespace returns the namespace of the value that was about to be transformed when the transformation was aborted.
+func (e *ErrCanceled) Namespace() string {
+	return e.ns
+}
+
+// Processed returns the number of fields, elements and map entries that were transformed before aborting.
+func (e *ErrCanceled) Processed() int {
+	return e.processed
+}
+
+// Error returns the ErrCanceled message.
+func (e *ErrCanceled) Error() string {
+	return fmt.Sprintf("mold: transformation aborted at '%s' after %d values: %s", e.ns, e.processed, e.err)
+}
+
+// Unwrap returns the error of the context e. g. context.Canceled or context.DeadlineExceeded.
+func (e *ErrCanceled) Unwrap() error {
+	return e.err
+}
+
+// ErrCycle describes a value which refers back to a value containing it,
+// e. g. a pointer to a struct which is being transformed.
+type ErrCycle struct {
+	ns  string
+	typ reflect.Type
+}
+
+// Namespace returns the namespace of the value closing the cycle.
+func (e *ErrCycle) Namespace() string {
+	return e.ns
+}
+
+// Error returns the ErrCycle message.
+func (e *ErrCycle) Error() string {
+	return fmt.Sprintf("mold: cycle detected at '%s' of type %s", e.ns, e.typ)
+}
+
+// ErrMaxDepth describes a value which is nested deeper than the maximum depth set with SetMaxDepth.
+type ErrMaxDepth struct {
+	ns    string
+	depth int
+}
+
+// Namespace returns the namespace of the value exceeding the maximum depth.
+func (e *ErrMaxDepth) Namespace() string {
+	return e.ns
+}
+
+// Depth returns the maximum depth that was exceeded.
+func (e *ErrMaxDepth) Depth() int {
+	return e.depth
+}
+
+// Error returns the ErrMaxDepth message.
+func (e *ErrMaxDepth) Error() string {
+	return fmt.Sprintf("mold: maximum depth of %d exceeded at '%s'", e.depth, e.ns)
+}
+
+// TransformError contains a single error returned by a transformation function.
+type TransformError struct {
+	ns        string
+	structNs  string
+	tag       string
+	actualTag string
+	param     string
+	err       error
+}
+
+// Namespace returns the namespace of the field that failed
+// e. g. User.Address[1].Phone,
+// field names are taken from the TagNameFunc when one is registered.
+func (e *TransformError) Namespace() string {
+	return e.ns
+}
+
+// StructNamespace returns the namespace of the field that failed
+// using the actual Go field names.
+func (e *TransformError) StructNamespace() string {
+	return e.structNs
+}
+
+// Tag returns the tag, or alias, of the transformation that failed.
+// It is empty when the error was returned by a StructLevelFunc.
+func (e *TransformError) Tag() string {
+	return e.tag
+}
+
+// ActualTag returns the actual tag of the transformation that failed,
+// in case of an alias the actual tag within the alias will be returned.
+func (e *TransformError) ActualTag() string {
+	return e.actualTag
+}
+
+// Param returns the param of the transformation that failed.
+func (e *TransformError) Param() string {
+	return e.param
+}
+
+// Error returns the TransformError message.
+func (e *TransformError) Error() string {
+	if len(e.tag) == 0 {
+		return fmt.Sprintf("transformation of '%s' failed: %s", e.ns, e.err)
+	}
+	return fmt.Sprintf("transformation '%s' of '%s' failed: %s", e.tag, e.ns, e.err)
+}
+
+// Unwrap returns the error returned by the transformation function.
+func (e *TransformError) Unwrap() error {
+	return e.err
+}
+
+// TransformErrors is an array of TransformError's,
+// returned when a Transformer collects errors.
+type TransformErrors []*TransformError
+
+// Error returns the messages of all TransformErrors, one per line.
+func (e TransformErrors) Error() string {
+	buff := new(strings.Builder)
+	for i, err := range e {
+		if i > 0 {
+			buff.WriteByte('\n')
+		}
+		buff.WriteString(err.Error())
+	}
+
+	return buff.String()
+}
+
+// CompileError describes invalid tags of a struct field found by Compile.
+type CompileError struct {
+	ns  string
+	err error
+}
+
+// Namespace returns the path of the field with invalid tags
+// from the type passed to Compile using the actual Go field names e. g. User.Address.Phone.
+func (e *CompileError) Namespace() string {
+	return e.ns
+}
+
+// Error returns the CompileError message.
+func (e *CompileError) Error() string {
+	return fmt.Sprintf("invalid tags of '%s': %s", e.ns, e.err)
+}
+
+// Unwrap returns the error of parsing the tags e. g. an *ErrUndefinedTag.
+func (e *CompileError) Unwrap() error {
+	return e.err
+}
+
+// CompileErrors is an array of CompileError's returned by Compile.
+type CompileErrors []*CompileError
+
+// Error returns the messages of all CompileErrors, one per line.
+func (e CompileErrors) Error() string {
+	buff := new(strings.Builder)
+	for i, err := range e {
+		if i > 0 {
+			buff.WriteByte('\n')
+		}
+		buff.WriteString(err.Error())
+	}
+
+	return buff.String()
+}
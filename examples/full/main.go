@@ -6,6 +6,7 @@ import (
 	"net/url"
 
 	"github.com/pchchv/form"
+	"github.com/pchchv/modifier"
 	"github.com/pchchv/modifier/modifiers"
 	"github.com/pchchv/modifier/scrubbers"
 	"github.com/pchchv/validator"
@@ -94,11 +95,13 @@ func main() {
 	// process request
 	// etc....
 
-	// working with the data is over, let's log it or save it somewhere
-	// there is sensitive PII data, it should be made sure that it is de-identified first
-	if err := scrub.Struct(context.Background(), &user); err != nil {
+	// let's log the data, it still contains sensitive PII data,
+	// so a de-identified copy is logged while the user itself is left untouched
+	scrubbed, err := modifier.Clone(context.Background(), scrub, user)
+	if err != nil {
 		log.Panic(err)
 	}
 
-	log.Printf("Scrubbed:%+v\n\n", user)
+	log.Printf("Scrubbed:%+v\n\n", scrubbed)
+	log.Printf("Original:%+v\n\n", user)
 }
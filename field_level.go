@@ -8,6 +8,8 @@ var _ FieldLevel = (*fieldLevel)(nil)
 type FieldLevel interface {
 	// Transformer represents a subset of the current *Transformer that is executing the current transformation.
 	Transformer() Transform
+	// Top returns the top level value passed to Struct or Field.
+	Top() reflect.Value
 	// Parent returns the top level parent of the current value return by Field()
 	// This is used primarily for having the ability to nil out pointer type values.
 	// NOTE: that is there are several layers of abstractions
@@ -16,15 +18,45 @@ type FieldLevel interface {
 	Parent() reflect.Value
 	// Field returns the current field value being modified.
 	Field() reflect.Value
-	// Param returns the param associated wth the given function modifier.
+	// FieldName returns the field's name with the tag name taking precedence over the field's actual name,
+	// for elements of slices, arrays and maps the index or key is included e. g. Address[1].
+	FieldName() string
+	// StructFieldName returns the struct field's actual name.
+	StructFieldName() string
+	// Namespace returns the namespace of the field with the tag name taking precedence over the field's actual name
+	// e. g. User.Address[1].Phone
+	Namespace() string
+	// StructNamespace returns the namespace of the field with the struct field's actual name.
+	StructNamespace() string
+	// StructField returns the struct field the current value belongs to,
+	// it returns false when the value was not reached through a struct field e. g. when calling Field.
+	StructField() (reflect.StructField, bool)
+	// Param returns the param associated wth the given function modifier,
+	// quoted arguments are returned without their quotes.
 	Param() string
+	// Params returns the positional and named arguments of the param e. g. `substr=1 3`.
+	Params() Params
+	// GetStructFieldOK returns the value, kind and whether the field with the given name was found,
+	// the name is resolved relative to the struct containing the current field
+	// and may reference nested values e. g. Address[0].Phone or Misc[key].
+	// Pointers, interfaces and registered interceptors are followed along the way.
+	GetStructFieldOK(name string) (reflect.Value, reflect.Kind, bool)
+	// GetStructFieldOKAdvanced does the same as GetStructFieldOK,
+	// except that the namespace is resolved starting from val
+	// e. g. fl.GetStructFieldOKAdvanced(fl.Top(), "Address[0].Phone").
+	GetStructFieldOKAdvanced(val reflect.Value, namespace string) (reflect.Value, reflect.Kind, bool)
 }
 
 type fieldLevel struct {
-	transformer *Transformer
-	parent      reflect.Value
-	current     reflect.Value
-	param       string
+	tr      *transform
+	parent  reflect.Value
+	current reflect.Value
+	ct      *cTag
+	path    fieldPath
+}
+
+func (f fieldLevel) Top() reflect.Value {
+	return f.tr.top
 }
 
 func (f fieldLevel) Parent() reflect.Value {
@@ -35,10 +67,50 @@ func (f fieldLevel) Field() reflect.Value {
 	return f.current
 }
 
+func (f fieldLevel) FieldName() string {
+	ns, name := f.path.namespace(nil, true)
+	return string(ns[name:])
+}
+
+func (f fieldLevel) StructFieldName() string {
+	ns, name := f.path.namespace(nil, false)
+	return string(ns[name:])
+}
+
+func (f fieldLevel) Namespace() string {
+	return f.path.ns()
+}
+
+func (f fieldLevel) StructNamespace() string {
+	return f.path.structNs()
+}
+
+func (f fieldLevel) StructField() (reflect.StructField, bool) {
+	if f.path.cf == nil {
+		return reflect.StructField{}, false
+	}
+	return f.path.cf.fld, true
+}
+
 func (f fieldLevel) Param() string {
-	return f.param
+	return f.ct.param
+}
+
+func (f fieldLevel) Params() Params {
+	return f.ct.params
 }
 
 func (f fieldLevel) Transformer() Transform {
-	return f.transformer
+	return f.tr.t
+}
+
+func (f fieldLevel) GetStructFieldOK(name string) (reflect.Value, reflect.Kind, bool) {
+	if !f.path.structValue.IsValid() {
+		return reflect.Value{}, reflect.Invalid, false
+	}
+	return f.tr.t.getStructFieldOK(f.path.structValue, name)
+}
+
+func (f fieldLevel) GetStructFieldOKAdvanced(val reflect.Value, namespace string) (reflect.Value, reflect.Kind, bool) {
+	return f.tr.t.getStructFieldOK(val, namespace)
 }
@@ -5,14 +5,17 @@ go 1.24.0
 require (
 	github.com/pchchv/go-assert v1.0.0
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/tools v0.34.0
 )
 
 require (
 	github.com/gabriel-vasile/mimetype v1.4.9 // indirect
 	github.com/gosimple/unidecode v1.0.1 // indirect
 	github.com/leodido/go-urn v1.4.0 // indirect
-	golang.org/x/crypto v0.38.0 // indirect
-	golang.org/x/net v0.39.0 // indirect
+	golang.org/x/crypto v0.39.0 // indirect
+	golang.org/x/mod v0.25.0 // indirect
+	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sync v0.15.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 )
 
@@ -25,5 +28,5 @@ require (
 	github.com/segmentio/go-camelcase v0.0.0-20160726192923-7085f1e3c734
 	github.com/segmentio/go-snakecase v1.2.0
 	golang.org/x/text v0.26.0
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,77 @@
+// Package interceptors provides interceptors for the nullable types of database/sql,
+// so that transformations are applied to the inner value and Valid is set once a value has been assigned:
+//
+//	conform := modifiers.New()
+//	interceptors.Register(conform)
+//	interceptors.RegisterNull[uuid.UUID](conform)
+package interceptors
+
+import (
+	"database/sql"
+	"reflect"
+
+	"github.com/pchchv/modifier"
+)
+
+// Register registers the interceptors for sql.NullString, sql.NullInt64, sql.NullInt32,
+// sql.NullInt16, sql.NullByte, sql.NullFloat64, sql.NullBool and sql.NullTime with t.
+//
+// NOTE: like the Register methods of the Transformer, it must be called prior to any transformation.
+func Register(t *modifier.Transformer) {
+	t.RegisterInterceptorWithCommit(Value, Commit,
+		sql.NullString{},
+		sql.NullInt64{},
+		sql.NullInt32{},
+		sql.NullInt16{},
+		sql.NullByte{},
+		sql.NullFloat64{},
+		sql.NullBool{},
+		sql.NullTime{},
+	)
+}
+
+// RegisterNull registers the interceptor for the generic sql.Null[T] with t.
+// As every T is a distinct type, it has to be registered for each of them.
+func RegisterNull[T any](t *modifier.Transformer) {
+	t.RegisterInterceptorWithCommit(Value, Commit, sql.Null[T]{})
+}
+
+// Value is an InterceptorFunc returning the value of a nullable type of database/sql,
+// which is it's first field, e. g. String of sql.NullString or V of sql.Null[T].
+func Value(current reflect.Value) (inner reflect.Value) {
+	return current.Field(0)
+}
+
+// names of the tags of the modifiers package assigning values, which Commit depends on.
+const (
+	setTag     = "set"
+	defaultTag = "default"
+)
+
+// Commit is a CommitFunc setting the Valid field of a nullable type of database/sql
+// once a value has been assigned to it, either by the set and default tags of the modifiers package,
+// which may assign the zero value e. g. set=false, or by a transformation changing it to a value other than the zero value,
+// so that trimming a NULL string keeps it NULL. As default only assigns a value to the zero value,
+// it leaves a non-zero but NULL value NULL. Valid is never reset,
+// so that an empty, but valid, value does not become NULL.
+//
+// NOTE: the tags are recognized by their names, if the transformations of the modifiers package
+// are registered under other names, only their changes to values other than the zero value set Valid.
+func Commit(current, inner reflect.Value, tag string, changed bool) {
+	switch tag {
+	case setTag:
+	case defaultTag:
+		// a value other than the zero value is kept as is by default
+		if !changed && !inner.IsZero() {
+			return
+		}
+	default:
+		if !changed || inner.IsZero() {
+			return
+		}
+	}
+
+	if valid := current.FieldByName("Valid"); valid.CanSet() {
+		valid.SetBool(true)
+	}
+}
@@ -0,0 +1,70 @@
+package interceptors
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	. "github.com/pchchv/go-assert"
+	"github.com/pchchv/modifier/modifiers"
+)
+
+type Row struct {
+	Name    sql.NullString   `mod:"trim,default=x"`
+	Nick    sql.NullString   `mod:"trim"`
+	Empty   sql.NullString   `mod:"trim"`
+	Age     sql.NullInt64    `mod:"default=18"`
+	Small   sql.NullInt32    `mod:"set=3"`
+	Tiny    sql.NullInt16    `mod:"default=2"`
+	Byte    sql.NullByte     `mod:"default=1"`
+	Score   sql.NullFloat64  `mod:"set=1.5"`
+	Active  sql.NullBool     `mod:"default=true"`
+	Created sql.NullTime     `mod:"default"`
+	Code    sql.Null[string] `mod:"trim,ucase"`
+	Count   sql.Null[int]    `mod:"default=5"`
+	False   sql.NullBool     `mod:"set=false"`
+	Zero    sql.NullInt64    `mod:"set=0"`
+	Default sql.NullInt64    `mod:"default=0"`
+	Garbage sql.NullString   `mod:"trim"`
+	Kept    sql.NullInt64    `mod:"default=18"`
+}
+
+func TestNullTypes(t *testing.T) {
+	conform := modifiers.New()
+	Register(conform)
+	RegisterNull[string](conform)
+	RegisterNull[int](conform)
+
+	row := Row{
+		Nick:    sql.NullString{String: "  ", Valid: true},
+		Code:    sql.Null[string]{V: " ab "},
+		Garbage: sql.NullString{String: " "},
+		Kept:    sql.NullInt64{Int64: 5},
+	}
+	err := conform.Struct(context.Background(), &row)
+	Equal(t, err, nil)
+	Equal(t, row.Name, sql.NullString{String: "x", Valid: true})
+	Equal(t, row.Nick, sql.NullString{String: "", Valid: true})
+	Equal(t, row.Empty, sql.NullString{})
+	Equal(t, row.Age, sql.NullInt64{Int64: 18, Valid: true})
+	Equal(t, row.Small, sql.NullInt32{Int32: 3, Valid: true})
+	Equal(t, row.Tiny, sql.NullInt16{Int16: 2, Valid: true})
+	Equal(t, row.Byte, sql.NullByte{Byte: 1, Valid: true})
+	Equal(t, row.Score, sql.NullFloat64{Float64: 1.5, Valid: true})
+	Equal(t, row.Active, sql.NullBool{Bool: true, Valid: true})
+	Equal(t, row.Created.Valid, true)
+	Equal(t, time.Since(row.Created.Time) < time.Minute, true)
+	Equal(t, row.Code, sql.Null[string]{V: "AB", Valid: true})
+	Equal(t, row.Count, sql.Null[int]{V: 5, Valid: true})
+	Equal(t, row.False, sql.NullBool{Bool: false, Valid: true})
+	Equal(t, row.Zero, sql.NullInt64{Int64: 0, Valid: true})
+	Equal(t, row.Default, sql.NullInt64{Int64: 0, Valid: true})
+	Equal(t, row.Garbage, sql.NullString{})
+	Equal(t, row.Kept, sql.NullInt64{Int64: 5})
+
+	s := &sql.NullString{String: " y "}
+	err = conform.Field(context.Background(), &s, "trim")
+	Equal(t, err, nil)
+	Equal(t, *s, sql.NullString{String: "y", Valid: true})
+}
@@ -2,14 +2,20 @@ package modifier
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var (
 	timeType           = reflect.TypeOf(time.Time{})
+	generatedType      = reflect.TypeOf((*Generated)(nil)).Elem()
+	moldableType       = reflect.TypeOf((*Moldable)(nil)).Elem()
 	restrictedTagErr   = "Tag '%s' either contains restricted characters or is the same as a restricted tag needed for normal operation"
 	restrictedAliasErr = "Alias '%s' either contains restricted characters or is the same as a restricted tag needed for normal operation"
 )
@@ -18,9 +24,44 @@ var (
 // E. g. sql.NullString, the manipulation should be done on the inner string.
 type InterceptorFunc func(current reflect.Value) (inner reflect.Value)
 
+// CommitFunc is called with an intercepted value and it's inner value,
+// as returned by the InterceptorFunc, after a transformation has been applied to the inner value.
+// It's passed the tag of the transformation and whether it changed the value,
+// values which cannot be compared are assumed to be changed.
+// E. g. to set sql.NullString.Valid once a value has been set.
+type CommitFunc func(current, inner reflect.Value, tag string, changed bool)
+
 // Func defines a transform function for use.
 type Func func(ctx context.Context, fl FieldLevel) error
 
+// Generated is implemented by types for which code has been generated by cmd/moldgen.
+// MoldTag applies the transformations of the struct tags named tagName
+// without reflection and reports whether code was generated for tagName.
+type Generated interface {
+	MoldTag(ctx context.Context, tagName string) (bool, error)
+}
+
+// Moldable is implemented by types which conform themselves,
+// e. g. an Email type normalizing it's value. Mold is called for every value
+// of such a type which is transformed, with a pointer or value receiver,
+// after the tags of the value have been applied, or before, see SetMoldFirst.
+type Moldable interface {
+	Mold(ctx context.Context) error
+}
+
+// Middleware wraps the Func registered for a tag, it is called once for every occurrence
+// of the tag when the tags are parsed, the FieldLevel passed to the returned Func
+// provides the metadata of the field being transformed.
+type Middleware func(tag, param string, next Func) Func
+
+// FilterFunc is the type used to filter fields using StructFiltered,
+// it is called with the struct namespace of each field, slice or array element and map entry
+// e. g. User.Addresses[0].City, returning true results in the value being skipped.
+// The namespace must not be retained or modified.
+// With a context created by WithParallelism it is called from multiple goroutines at once,
+// so it must be safe for concurrent use.
+type FilterFunc func(ns []byte) bool
+
 // StructLevelFunc accepts all values needed for struct level manipulation.
 // This is needed for structs that may not be accessed or allowed to add tags from other packages in use.
 type StructLevelFunc func(ctx context.Context, sl StructLevel) error
@@ -39,9 +80,193 @@ type Transformer struct {
 	aliases          map[string]string
 	transformations  map[string]Func
 	structLevelFuncs map[reflect.Type]StructLevelFunc
+	structRules      map[reflect.Type]map[string]string
 	interceptors     map[reflect.Type]InterceptorFunc
+	commits          map[reflect.Type]CommitFunc
+	middleware       []Middleware
+	tagNameFunc      TagNameFunc
 	cCache           *structCache
 	tCache           *tagCache
+	mCache           *moldCache
+	collectErrors    bool
+	useGenerated     bool
+	redactChanges    bool
+	skipLimited      bool
+	moldFirst        bool
+	maxDepth         int
+}
+
+// TagNameFunc allows for adding of a custom tag name parser.
+type TagNameFunc func(field reflect.StructField) string
+
+// transform holds the state of a single Struct or Field call.
+type transform struct {
+	t         *Transformer
+	top       reflect.Value
+	errs      TransformErrors
+	done      <-chan struct{}
+	processed *atomic.Int64
+	workers   chan struct{} // tokens of the additional goroutines which may be started
+	generated bool          // whether generated code may be used instead of reflection
+	record    bool          // whether changes are recorded
+	changes   []Change
+	filter    FilterFunc
+	buf       []byte   // namespace passed to the filter
+	groups    []string // groups whose tags are applied
+	groupKey  string   // groups as used by the struct cache
+}
+
+// newTransform returns the state for a call of t on top using ctx.
+func newTransform(ctx context.Context, t *Transformer, top reflect.Value) *transform {
+	tr := &transform{t: t, top: top, done: ctx.Done(), groups: groups(ctx)}
+	tr.groupKey = strings.Join(tr.groups, tagSeparator)
+	if tr.done != nil {
+		// values are only counted for ErrCanceled
+		tr.processed = new(atomic.Int64)
+	}
+
+	if n := parallelism(ctx); n > 1 {
+		tr.workers = make(chan struct{}, n-1)
+	}
+
+	// generated code only knows about the tags of the fields
+	tr.generated = t.useGenerated && !t.collectErrors && tr.workers == nil &&
+		len(t.structLevelFuncs) == 0 && len(t.structRules) == 0 && len(t.interceptors) == 0 && len(t.middleware) == 0 && t.maxDepth == 0 && len(tr.groups) == 0
+	return tr
+}
+
+// fork returns a copy of the state which can be used by another goroutine,
+// errors collected by it must be merged back.
+func (tr *transform) fork() *transform {
+	return &transform{t: tr.t, top: tr.top, done: tr.done, processed: tr.processed, workers: tr.workers, generated: tr.generated, filter: tr.filter, groups: tr.groups, groupKey: tr.groupKey}
+}
+
+// parallel splits n values into ranges and calls fn for each of them,
+// using additional goroutines as long as workers are available.
+// Collected errors are merged in the order of the ranges and
+// the error of the first range that failed is returned,
+// so the result does not depend on the scheduling of the goroutines.
+func (tr *transform) parallel(n int, fn func(w *transform, start, end int) error) error {
+	chunks := cap(tr.workers) + 1
+	if chunks > n {
+		chunks = n
+	}
+
+	size := (n + chunks - 1) / chunks
+	forks := make([]*transform, chunks)
+	errs := make([]error, chunks)
+	panics := make([]interface{}, chunks)
+	var wg sync.WaitGroup
+	for c := 0; c < chunks; c++ {
+		start, end := c*size, (c+1)*size
+		if end > n {
+			end = n
+		}
+
+		forks[c] = tr.fork()
+		if c < chunks-1 {
+			select {
+			case tr.workers <- struct{}{}:
+				wg.Add(1)
+				go func(c int) {
+					defer func() {
+						panics[c] = recover()
+						<-tr.workers
+						wg.Done()
+					}()
+					errs[c] = fn(forks[c], start, end)
+				}(c)
+				continue
+			default:
+			}
+		}
+		errs[c] = fn(forks[c], start, end)
+	}
+	wg.Wait()
+
+	for c := 0; c < chunks; c++ {
+		if panics[c] != nil {
+			panic(panics[c])
+		}
+
+		tr.errs = append(tr.errs, forks[c].errs...)
+		if errs[c] != nil {
+			return errs[c]
+		}
+	}
+
+	return nil
+}
+
+// checkCanceled counts the value at p as processed
+// and returns an ErrCanceled if the context of the call is done.
+func (tr *transform) checkCanceled(ctx context.Context, p fieldPath) error {
+	if tr.done == nil {
+		return nil
+	}
+
+	select {
+	case <-tr.done:
+		return &ErrCanceled{ns: p.ns(), processed: int(tr.processed.Load()), err: ctx.Err()}
+	default:
+		tr.processed.Add(1)
+		return nil
+	}
+}
+
+// fail records err for the field at p when errors are being collected,
+// otherwise err is returned as is.
+func (tr *transform) fail(p fieldPath, ct *cTag, err error) error {
+	if !tr.t.collectErrors {
+		return err
+	}
+
+	te := &TransformError{ns: p.ns(), structNs: p.structNs(), err: err}
+	if ct != nil {
+		te.tag = ct.aliasTag
+		te.actualTag = ct.tag
+		te.param = ct.param
+	}
+	tr.errs = append(tr.errs, te)
+	return nil
+}
+
+// filtered reports whether the value at p is skipped by the filter.
+func (tr *transform) filtered(p fieldPath) bool {
+	if tr.filter == nil {
+		return false
+	}
+
+	tr.buf, _ = p.namespace(tr.buf[:0], false)
+	return tr.filter(tr.buf)
+}
+
+// descend returns the path of the struct, slice, array or map v refers to,
+// which is below the value at p. If v is part of a cycle or too deeply nested
+// ok is false and either an error is returned or, if limited values are skipped, nil.
+func (tr *transform) descend(p fieldPath, v reflect.Value) (np fieldPath, ok bool, err error) {
+	np, cycle := p.descend(v)
+	switch {
+	case cycle:
+		err = &ErrCycle{ns: p.ns(), typ: v.Type()}
+	case tr.t.maxDepth > 0 && np.depth > tr.t.maxDepth:
+		err = &ErrMaxDepth{ns: p.ns(), depth: tr.t.maxDepth}
+	default:
+		return np, true, nil
+	}
+
+	if tr.t.skipLimited {
+		err = nil
+	}
+	return p, false, err
+}
+
+// result returns the final error of the transformation.
+func (tr *transform) result(err error) error {
+	if err == nil && len(tr.errs) > 0 {
+		return tr.errs
+	}
+	return err
 }
 
 // New creates a new Transform object with default tag name of 'mold'.
@@ -49,15 +274,19 @@ func New() *Transformer {
 	tc := new(tagCache)
 	tc.m.Store(make(map[string]*cTag))
 	sc := new(structCache)
-	sc.m.Store(make(map[reflect.Type]*cStruct))
+	sc.m.Store(make(map[structKey]*cStruct))
+	mc := new(moldCache)
+	mc.m.Store(make(map[reflect.Type]bool))
 
 	return &Transformer{
 		tagName:         "mold",
 		aliases:         make(map[string]string),
 		transformations: make(map[string]Func),
 		interceptors:    make(map[reflect.Type]InterceptorFunc),
+		commits:         make(map[reflect.Type]CommitFunc),
 		cCache:          sc,
 		tCache:          tc,
+		mCache:          mc,
 	}
 }
 
@@ -101,19 +330,77 @@ func (t *Transformer) RegisterStructLevel(fn StructLevelFunc, types ...interface
 //
 // NOTE: this method is not thread-safe. It is intended that these all be registered before hand.
 func (t *Transformer) RegisterAlias(alias, tags string) {
+	if err := t.ValidateAlias(alias, tags); err != nil {
+		panic(err.Error())
+	}
+
+	t.aliases[alias] = tags
+}
+
+// ValidateAlias returns the error RegisterAlias panics with for alias and tags, without registering the alias.
+// The tags are not parsed, as they may refer to aliases which are registered afterwards, see ValidateTagsWithAliases.
+func (t *Transformer) ValidateAlias(alias, tags string) error {
 	if len(alias) == 0 {
-		panic("Alias cannot be empty")
+		return errors.New("Alias cannot be empty")
 	}
 
 	if len(tags) == 0 {
-		panic("Aliased tags cannot be empty")
+		return errors.New("Aliased tags cannot be empty")
 	}
 
 	if _, ok := restrictedTags[alias]; ok || strings.ContainsAny(alias, restrictedTagChars) {
-		panic(fmt.Sprintf(restrictedAliasErr, alias))
+		return fmt.Errorf(restrictedAliasErr, alias)
 	}
+	return nil
+}
 
-	t.aliases[alias] = tags
+// Use adds middleware wrapping all transformation functions, the first one being the outermost.
+// The middleware is applied when tags are parsed, so that calling the functions adds no further overhead,
+// e.g. to collect metrics per tag, trace or recover from panics.
+//
+// NOTE: this method is not thread-safe. It is intended that all of them must be registered prior to any validation.
+func (t *Transformer) Use(mw ...Middleware) {
+	t.middleware = append(t.middleware, mw...)
+}
+
+// RegisterStructRules registers tags for the fields of one or more struct types,
+// e.g. for types generated from protobuf or OpenAPI definitions which cannot be tagged.
+// The rules map field names to tags, nested fields are separated by a dot and
+// resolved through pointers, slices, arrays and map values e.g. Address.City,
+// in which case the rule is registered for the struct type of the Address field
+// and applies to it wherever it is used, not only within the given types.
+// Rules replace the tags of a field, unless they are prefixed with a plus sign
+// e.g. +trim in which case they are appended to them.
+// It panics if a field does not exist or a different rule is already registered for it.
+//
+// NOTE: this method is not thread-safe. It is intended that all of them must be registered prior to any validation.
+func (t *Transformer) RegisterStructRules(rules map[string]string, types ...interface{}) {
+	if t.structRules == nil {
+		t.structRules = make(map[reflect.Type]map[string]string)
+	}
+
+	for _, v := range types {
+		typ, err := ruleStruct(v)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		for name, tags := range rules {
+			st, field, err := resolveRuleField(typ, name)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			if err = t.conflictingRule(typ, st, name, field, tags); err != nil {
+				panic(err.Error())
+			}
+
+			if t.structRules[st] == nil {
+				t.structRules[st] = make(map[string]string)
+			}
+			t.structRules[st][field] = tags
+		}
+	}
 }
 
 // RegisterInterceptor registers a new interceptor functions agains one or more types.
@@ -123,23 +410,115 @@ func (t *Transformer) RegisterAlias(alias, tags string) {
 func (t *Transformer) RegisterInterceptor(fn InterceptorFunc, types ...interface{}) {
 	for _, typ := range types {
 		t.interceptors[reflect.TypeOf(typ)] = fn
+		delete(t.commits, reflect.TypeOf(typ))
+	}
+}
+
+// RegisterInterceptorWithCommit registers an interceptor function like RegisterInterceptor
+// along with a CommitFunc which is called after each transformation of the inner value,
+// so that changes of the inner value can be written back to the intercepted value.
+// E. g. sql.NullString, where Valid must be set once String has been set.
+func (t *Transformer) RegisterInterceptorWithCommit(fn InterceptorFunc, commit CommitFunc, types ...interface{}) {
+	for _, typ := range types {
+		t.interceptors[reflect.TypeOf(typ)] = fn
+		t.commits[reflect.TypeOf(typ)] = commit
 	}
 }
 
 // Struct applies transformations against the provided struct.
 func (t *Transformer) Struct(ctx context.Context, v interface{}) error {
+	_, err := t.transformStruct(ctx, v, "Struct", false, nil)
+	return err
+}
+
+// StructPartial applies transformations against the provided struct like Struct,
+// but only to the given fields, along with the values they contain.
+// Fields are namespaced relative to the struct using the actual Go field names
+// e. g. Name, Address.City or Addresses[0].City, values leading to them are traversed,
+// their tags, like dive, are applied as well.
+func (t *Transformer) StructPartial(ctx context.Context, v interface{}, fields ...string) error {
+	names := filterNames(v, fields)
+	_, err := t.transformStruct(ctx, v, "StructPartial", false, func(ns []byte) bool {
+		for _, name := range names {
+			if within(ns, name) || within([]byte(name), string(ns)) {
+				return false
+			}
+		}
+		return true
+	})
+	return err
+}
+
+// StructExcept applies transformations against the provided struct like Struct,
+// except to the given fields and the values they contain.
+// Fields are namespaced like for StructPartial.
+func (t *Transformer) StructExcept(ctx context.Context, v interface{}, fields ...string) error {
+	names := filterNames(v, fields)
+	_, err := t.transformStruct(ctx, v, "StructExcept", false, func(ns []byte) bool {
+		for _, name := range names {
+			if within(ns, name) {
+				return true
+			}
+		}
+		return false
+	})
+	return err
+}
+
+// StructFiltered applies transformations against the provided struct like Struct,
+// skipping the fields, elements and map entries for which fn returns true.
+func (t *Transformer) StructFiltered(ctx context.Context, v interface{}, fn FilterFunc) error {
+	_, err := t.transformStruct(ctx, v, "StructFiltered", false, fn)
+	return err
+}
+
+// StructChanges applies transformations against the provided struct like Struct
+// and returns the changes made by the transformation functions in the order they were made.
+// In case of an error the changes made so far are returned along with it.
+//
+// NOTE: changes made by StructLevelFuncs are not recorded and parallelism is not used.
+func (t *Transformer) StructChanges(ctx context.Context, v interface{}) ([]Change, error) {
+	return t.transformStruct(ctx, v, "StructChanges", true, nil)
+}
+
+// DryRun returns the changes Struct would make to the provided struct, or pointer to struct,
+// by transforming a deep copy of it, see StructCopy and StructChanges.
+func (t *Transformer) DryRun(ctx context.Context, v interface{}) (changes []Change, err error) {
+	_, err = t.structCopy(ctx, v, "DryRun", func(cp interface{}) (err error) {
+		changes, err = t.transformStruct(ctx, cp, "DryRun", true, nil)
+		return
+	})
+	return
+}
+
+// transformStruct applies transformations against the provided struct,
+// skipping the values filter returns true for, and returns the changes if they are recorded.
+func (t *Transformer) transformStruct(ctx context.Context, v interface{}, fn string, record bool, filter FilterFunc) ([]Change, error) {
 	orig := reflect.ValueOf(v)
 	if orig.Kind() != reflect.Ptr || orig.IsNil() {
-		return &ErrInvalidTransformValue{typ: reflect.TypeOf(v), fn: "Struct"}
+		return nil, &ErrInvalidTransformValue{typ: reflect.TypeOf(v), fn: fn}
 	}
 
 	val := orig.Elem()
 	typ := val.Type()
 	if val.Kind() != reflect.Struct || val.Type() == timeType {
-		return &ErrInvalidTransformation{typ: reflect.TypeOf(v)}
+		return nil, &ErrInvalidTransformation{typ: reflect.TypeOf(v)}
 	}
 
-	return t.setByStruct(ctx, orig, val, typ)
+	tr := newTransform(ctx, t, orig)
+	if record {
+		// changes are recorded in order by the transformation functions
+		tr.record, tr.workers, tr.generated = true, nil, false
+	}
+
+	if filter != nil {
+		// generated code transforms all fields
+		tr.filter, tr.generated = filter, false
+	}
+
+	p, _ := fieldPath{}.descend(orig)
+	err := tr.result(tr.setByStruct(ctx, orig, val, typ, p))
+	return tr.changes, err
 }
 
 // Field applies the provided transformations against the variable.
@@ -148,30 +527,132 @@ func (t *Transformer) Field(ctx context.Context, v interface{}, tags string) (er
 		return nil
 	}
 
-	val := reflect.ValueOf(v)
-	if val.Kind() != reflect.Ptr || val.IsNil() {
+	orig := reflect.ValueOf(v)
+	if orig.Kind() != reflect.Ptr || orig.IsNil() {
 		return &ErrInvalidTransformValue{typ: reflect.TypeOf(v), fn: "Field"}
 	}
 
-	val = val.Elem()
-	// find cached tag
+	val := orig.Elem()
+	ctag, err := t.extractTagCache(tags)
+	if err != nil {
+		return
+	}
+
+	tr := newTransform(ctx, t, orig)
+	return tr.result(tr.setByField(ctx, val, fieldPath{}, ctag))
+}
+
+// Map transforms the values of m according to rules, which map keys of m either to tags,
+// as passed to Field, or to nested rules for values which are a map[string]interface{} themselves
+// e. g. {"name": "trim,title", "address": {"city": "trim"}}.
+// Keys missing from m are skipped and the transformed values are written back into m.
+func (t *Transformer) Map(ctx context.Context, m map[string]interface{}, rules map[string]interface{}) error {
+	tr := newTransform(ctx, t, reflect.ValueOf(m))
+	return tr.result(tr.setByRules(ctx, m, rules, fieldPath{}))
+}
+
+// extractTagCache returns the parsed tags from the cache, parsing them if not found.
+func (t *Transformer) extractTagCache(tags string) (ctag *cTag, err error) {
 	ctag, ok := t.tCache.Get(tags)
 	if !ok {
 		t.tCache.lock.Lock()
+		defer t.tCache.lock.Unlock()
 		// could have been multiple trying to access,
 		// but once first is done this ensures tag isn't parsed again
 		ctag, ok = t.tCache.Get(tags)
 		if !ok {
-			if ctag, _, err = t.parseFieldTagsRecursive(tags, "", "", false); err != nil {
-				t.tCache.lock.Unlock()
+			if ctag, _, err = t.parseFieldTagsRecursive(tags, "", "", false, nil); err != nil {
 				return
 			}
 			t.tCache.Set(tags, ctag)
 		}
-		t.tCache.lock.Unlock()
+	}
+	return
+}
+
+// Compile parses and caches the tags of the struct types of the given values,
+// which may also be reflect.Types, along with all struct types they contain,
+// so that invalid tags are found at startup instead of on first use.
+// The invalid tags of all fields are returned as CompileErrors.
+func (t *Transformer) Compile(types ...interface{}) error {
+	var errs CompileErrors
+	seen := make(map[reflect.Type]bool)
+	for _, v := range types {
+		typ, ok := v.(reflect.Type)
+		if !ok {
+			typ = reflect.TypeOf(v)
+		}
+
+		if typ != nil {
+			t.compile(typ, "", seen, &errs)
+		}
 	}
 
-	return t.setByField(ctx, val, ctag)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateTags parses tags as if they were found on the struct field named field
+// and returns the error Struct would return for them, e.g. an *ErrUndefinedTag.
+func (t *Transformer) ValidateTags(field, tags string) error {
+	return t.ValidateTagsWithAliases(field, tags, nil)
+}
+
+// ValidateTagsWithAliases parses tags like ValidateTags as if the given aliases were registered,
+// in addition to the registered ones, e.g. to validate tags along with the aliases they use before registering them.
+func (t *Transformer) ValidateTagsWithAliases(field, tags string, aliases map[string]string) error {
+	if len(tags) == 0 || tags == ignoreTag {
+		return nil
+	}
+
+	if len(aliases) > 0 {
+		// only the aliases of the copy are used to parse the tags
+		c := *t
+		c.aliases = make(map[string]string, len(t.aliases)+len(aliases))
+		for k, v := range t.aliases {
+			c.aliases[k] = v
+		}
+		for k, v := range aliases {
+			c.aliases[k] = v
+		}
+		t = &c
+	}
+
+	_, _, err := t.parseFieldTagsRecursive(tags, field, "", false, nil)
+	return err
+}
+
+// ValidateStructRule returns the error RegisterStructRules panics with if the field of the rule
+// does not exist in the struct type of v or a different rule is already registered for it,
+// or the error of it's tags like ValidateTagsWithAliases, without registering the rule.
+func (t *Transformer) ValidateStructRule(v interface{}, field, tags string, aliases map[string]string) error {
+	typ, err := ruleStruct(v)
+	if err != nil {
+		return err
+	}
+
+	st, name, err := resolveRuleField(typ, field)
+	if err != nil {
+		return err
+	}
+
+	if err = t.conflictingRule(typ, st, field, name, tags); err != nil {
+		return err
+	}
+	return t.ValidateTagsWithAliases(name, strings.TrimPrefix(tags, ruleMergePrefix), aliases)
+}
+
+// StructRuleField returns the struct type and the name of the field a struct rule
+// for the struct type of v registers tags for, e.g. the type of the Address field and City for Address.City.
+// Rules resolving to the same struct type and field conflict unless their tags are equal.
+func StructRuleField(v interface{}, field string) (reflect.Type, string, error) {
+	typ, err := ruleStruct(v)
+	if err != nil {
+		return nil, "", err
+	}
+	return resolveRuleField(typ, field)
 }
 
 // SetTagName sets the given tag name to be used.
@@ -180,20 +661,172 @@ func (t *Transformer) SetTagName(tagName string) {
 	t.tagName = tagName
 }
 
-func (t *Transformer) setByField(ctx context.Context, orig reflect.Value, ct *cTag) (err error) {
-	current, kind := t.extractType(orig)
+// RegisterTagNameFunc registers a function to get alternate names for StructFields,
+// used by FieldLevel.FieldName, FieldLevel.Namespace and TransformError.Namespace.
+// E. g. to use the names which have been specified for JSON representations of structs,
+// rather than normal Go field names:
+//
+//	t.RegisterTagNameFunc(func(fld reflect.StructField) string {
+//		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+//		if name == "-" {
+//			return ""
+//		}
+//		return name
+//	})
+//
+// An empty name falls back to the Go field name.
+//
+// NOTE: this method is not thread-safe. It is intended that it be registered before any transformation.
+func (t *Transformer) RegisterTagNameFunc(fn TagNameFunc) {
+	t.tagNameFunc = fn
+}
+
+// SetUseGenerated sets whether the MoldTag method of types implementing Generated,
+// which is emitted by cmd/moldgen, is used instead of reflection. Default is false.
+// Generated code is never used when errors are collected, with parallelism or groups
+// or if struct level transformations, struct rules, interceptors or middleware are registered,
+// nor for types which may refer to themselves e. g. Node{Next *Node}, as it does not detect cycles.
+//
+// NOTE: generated code calls the built-in transformations of the modifiers and scrubbers packages,
+// it must not be used with a Transformer which registers other functions for these tags.
+func (t *Transformer) SetUseGenerated(use bool) {
+	t.useGenerated = use
+}
+
+// SetRedactChanges sets whether the values before and after a Change are replaced by a hash,
+// so that changes can be logged without disclosing the data. Default is false.
+func (t *Transformer) SetRedactChanges(redact bool) {
+	t.redactChanges = redact
+}
+
+// SetMaxDepth sets the maximum number of nested structs, slices, arrays and maps
+// which are transformed, the struct passed to Struct being the first.
+// Deeper values return an *ErrMaxDepth or are skipped, see SetSkipLimited.
+// Default is 0, which doesn't limit the depth.
+// Generated code is not used when a maximum depth is set.
+//
+// NOTE: this method is not thread-safe. It is intended that it be set before any transformation.
+func (t *Transformer) SetMaxDepth(depth int) {
+	t.maxDepth = depth
+}
+
+// SetSkipLimited sets whether values exceeding the maximum depth and values referring back to
+// a value containing them, e.g. a pointer to the struct being transformed, are skipped.
+// Default is false, an *ErrMaxDepth or *ErrCycle is returned.
+//
+// NOTE: this method is not thread-safe. It is intended that it be set before any transformation.
+func (t *Transformer) SetSkipLimited(skip bool) {
+	t.skipLimited = skip
+}
+
+// SetMoldFirst sets whether the Mold method of Moldable values is called before their tags are applied,
+// for structs before their StructLevelFunc and fields. Default is false, it's called afterwards.
+//
+// NOTE: this method is not thread-safe. It is intended that it be set before any transformation.
+func (t *Transformer) SetMoldFirst(first bool) {
+	t.moldFirst = first
+}
+
+// SetCollectErrors sets whether transformations keep going after a Func returns an error.
+// When enabled the remaining tags of the failing field are skipped,
+// the rest of the value is still transformed and
+// Struct and Field return all errors as TransformErrors.
+// Default is false, the first error is returned as is.
+//
+// NOTE: this method is not thread-safe. It is intended that it be set before any transformation.
+func (t *Transformer) SetCollectErrors(collect bool) {
+	t.collectErrors = collect
+}
+
+func (tr *transform) setByField(ctx context.Context, orig reflect.Value, p fieldPath, ct *cTag) (err error) {
+	// structs are molded by setByStruct
+	current, kind := tr.t.extractType(orig)
+	if kind == reflect.Struct || !tr.t.moldable(current, p) {
+		return tr.setByTags(ctx, orig, current, kind, p, ct)
+	}
+
+	if tr.t.moldFirst {
+		if err = tr.mold(ctx, orig, current, p); err != nil {
+			return
+		}
+		current, kind = tr.t.extractType(orig)
+		return tr.setByTags(ctx, orig, current, kind, p, ct)
+	}
+
+	if err = tr.setByTags(ctx, orig, current, kind, p, ct); err != nil {
+		return
+	}
+
+	// value could have been changed or reassigned
+	if current, kind = tr.t.extractType(orig); kind != reflect.Struct && tr.t.moldable(current, p) {
+		err = tr.mold(ctx, orig, current, p)
+	}
+	return
+}
+
+// mold calls the Mold method of current, which orig refers to.
+func (tr *transform) mold(ctx context.Context, orig, current reflect.Value, p fieldPath) error {
+	var err error
+	switch {
+	case current.CanAddr() && reflect.PointerTo(current.Type()).Implements(moldableType):
+		err = current.Addr().Interface().(Moldable).Mold(ctx)
+	case current.Type().Implements(moldableType):
+		err = current.Interface().(Moldable).Mold(ctx)
+	default:
+		newVal := reflect.New(current.Type())
+		newVal.Elem().Set(current)
+		if err = newVal.Interface().(Moldable).Mold(ctx); err == nil {
+			orig.Set(newVal.Elem())
+		}
+	}
+
+	if err != nil {
+		return tr.fail(p, nil, err)
+	}
+	return nil
+}
+
+// setByTags applies the tags ct to the value orig, whose underlying value is current,
+// and transforms the struct it may refer to.
+func (tr *transform) setByTags(ctx context.Context, orig, current reflect.Value, kind reflect.Kind, p fieldPath, ct *cTag) (err error) {
 	if ct != nil && ct.hasTag {
 		for ct != nil {
 			switch ct.typeof {
 			case typeEndKeys:
 				return
+			case typeOmitEmpty:
+				if !current.IsValid() || current.IsZero() {
+					return
+				}
+				ct = ct.next
+			case typeOmitNil:
+				switch kind {
+				case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+					if current.IsNil() {
+						return
+					}
+				}
+				ct = ct.next
+			case typeIf, typeUnless:
+				if tr.t.evalGuard(p, ct.param) != (ct.typeof == typeIf) {
+					return
+				}
+				ct = ct.next
 			case typeDive:
 				ct = ct.next
+				switch kind {
+				case reflect.Slice, reflect.Array, reflect.Map:
+					var ok bool
+					if p, ok, err = tr.descend(p, orig); !ok {
+						return
+					}
+				}
+
 				switch kind {
 				case reflect.Slice, reflect.Array:
-					err = t.setByIterable(ctx, current, ct)
+					err = tr.setByIterable(ctx, current, p, ct)
 				case reflect.Map:
-					err = t.setByMap(ctx, current, ct)
+					err = tr.setByMap(ctx, current, p, ct)
 				case reflect.Ptr:
 					innerKind := current.Type().Elem().Kind()
 					if innerKind == reflect.Slice || innerKind == reflect.Map {
@@ -207,30 +840,10 @@ func (t *Transformer) setByField(ctx context.Context, orig reflect.Value, ct *cT
 				}
 				return
 			default:
-				if !current.CanAddr() {
-					newVal := reflect.New(current.Type()).Elem()
-					newVal.Set(current)
-					if err = ct.fn(ctx, fieldLevel{
-						transformer: t,
-						parent:      orig,
-						current:     newVal,
-						param:       ct.param,
-					}); err != nil {
-						return
-					}
-					orig.Set(reflect.Indirect(newVal))
-					current, kind = t.extractType(orig)
-				} else {
-					if err = ct.fn(ctx, fieldLevel{
-						transformer: t,
-						parent:      orig,
-						current:     current,
-						param:       ct.param,
-					}); err != nil {
-						return
-					}
-					// value could have been changed or reassigned
-					current, kind = t.extractType(current)
+				var failed bool
+				if current, kind, failed, err = tr.setByTag(ctx, orig, current, kind, p, ct); err != nil || failed {
+					// the remaining tags of a failing field are skipped
+					return
 				}
 				ct = ct.next
 			}
@@ -241,75 +854,326 @@ func (t *Transformer) setByField(ctx context.Context, orig reflect.Value, ct *cT
 	// previous sets could have set a struct value,
 	// where it was a nil pointer before
 	orig2 := current
-	current, kind = t.extractType(current)
+	current, kind = tr.t.extractType(current)
 	if kind == reflect.Struct {
 		typ := current.Type()
 		if typ == timeType {
 			return
 		}
 
+		var ok bool
+		if p, ok, err = tr.descend(p, orig); !ok {
+			return
+		}
+
 		if !current.CanAddr() {
 			newVal := reflect.New(typ).Elem()
 			newVal.Set(current)
 
-			if err = t.setByStruct(ctx, orig, newVal, typ); err != nil {
+			if err = tr.setByStruct(ctx, orig, newVal, typ, p); err != nil {
 				return
 			}
 			orig.Set(reflect.Indirect(newVal))
 			return
 		}
-		err = t.setByStruct(ctx, orig2, current, typ)
+		err = tr.setByStruct(ctx, orig2, current, typ, p)
 	}
 	return
 }
 
-func (t *Transformer) setByMap(ctx context.Context, current reflect.Value, ct *cTag) error {
-	for _, key := range current.MapKeys() {
-		newVal := reflect.New(current.Type().Elem()).Elem()
-		newVal.Set(current.MapIndex(key))
-		if ct != nil && ct.typeof == typeKeys && ct.keys != nil {
-			// remove current map key as we may be changing it
-			// and re-add to the map afterwards
-			current.SetMapIndex(key, reflect.Value{})
-			newKey := reflect.New(current.Type().Key()).Elem()
-			newKey.Set(key)
-			key = newKey
-			// handle map key
-			if err := t.setByField(ctx, key, ct.keys); err != nil {
-				return err
+// setByTag runs the transformation of ct, or its alternatives, against current
+// and returns the possibly changed current value, along with whether it failed
+// with the error being collected.
+func (tr *transform) setByTag(ctx context.Context, orig, current reflect.Value, kind reflect.Kind, p fieldPath, ct *cTag) (reflect.Value, reflect.Kind, bool, error) {
+	if ct.or == nil {
+		c, k, err := tr.callTag(ctx, orig, current, p, ct)
+		if err != nil {
+			if errors.Is(err, ErrNotApplicable) {
+				return current, kind, false, nil
 			}
+			return current, kind, true, tr.fail(p, ct, err)
+		}
+		return c, k, false, nil
+	}
+
+	// keep the original value so each alternative starts from it
+	var saved reflect.Value
+	if current.CanAddr() {
+		saved = reflect.New(current.Type()).Elem()
+		saved.Set(current)
+	}
+
+	var failed *cTag
+	var failedErr error
+	for alt := ct; alt != nil; alt = alt.or {
+		c, k, err := tr.callTag(ctx, orig, current, p, alt)
+		if err == nil {
+			return c, k, false, nil
+		}
+
+		if !errors.Is(err, ErrNotApplicable) {
+			failed, failedErr = alt, err
+		}
+
+		if saved.IsValid() {
+			current.Set(saved)
+		}
+	}
+
+	if failed == nil {
+		return current, kind, false, nil
+	}
+	return current, kind, true, tr.fail(p, failed, failedErr)
+}
+
+// callTag calls the transformation function of ct against current.
+func (tr *transform) callTag(ctx context.Context, orig, current reflect.Value, p fieldPath, ct *cTag) (reflect.Value, reflect.Kind, error) {
+	if !current.CanAddr() {
+		newVal := reflect.New(current.Type()).Elem()
+		newVal.Set(current)
+		var before interface{}
+		if tr.record {
+			before = tr.changeValue(newVal)
+		}
+		saved := tr.t.save(newVal)
+
+		if err := ct.fn(ctx, fieldLevel{
+			tr:      tr,
+			parent:  orig,
+			current: newVal,
+			ct:      ct,
+			path:    p,
+		}); err != nil {
+			return reflect.Value{}, reflect.Invalid, err
+		}
+
+		if tr.record {
+			tr.recordChange(p, ct, before, tr.changeValue(newVal))
+		}
+		orig.Set(reflect.Indirect(newVal))
+		tr.t.commit(orig, ct.tag, changed(saved, newVal))
+		current, kind := tr.t.extractType(orig)
+		return current, kind, nil
+	}
+
+	var before interface{}
+	if tr.record {
+		before = tr.changeValue(current)
+	}
+	saved := tr.t.save(current)
+
+	if err := ct.fn(ctx, fieldLevel{
+		tr:      tr,
+		parent:  orig,
+		current: current,
+		ct:      ct,
+		path:    p,
+	}); err != nil {
+		return reflect.Value{}, reflect.Invalid, err
+	}
+
+	if tr.record {
+		tr.recordChange(p, ct, before, tr.changeValue(current))
+	}
+	tr.t.commit(orig, ct.tag, changed(saved, current))
+	// value could have been changed or reassigned
+	current, kind := tr.t.extractType(current)
+	return current, kind, nil
+}
+
+// mapEntry is the result of transforming a map entry.
+type mapEntry struct {
+	key    reflect.Value
+	newKey reflect.Value
+	value  reflect.Value
+	keyed  bool // whether the key was transformed
+}
 
-			// can be nil when just keys being validated
-			if ct.next != nil {
-				if err := t.setByField(ctx, newVal, ct.next); err != nil {
-					return err
+func (tr *transform) setByMap(ctx context.Context, current reflect.Value, p fieldPath, ct *cTag) error {
+	keys := current.MapKeys()
+	if tr.workers != nil && len(keys) > 1 {
+		// the map may only be written to once all entries are transformed
+		entries := make([]mapEntry, len(keys))
+		if err := tr.parallel(len(keys), func(w *transform, start, end int) (err error) {
+			for i := start; i < end; i++ {
+				if entries[i], err = w.setByMapEntry(ctx, current, keys[i], p, ct); err != nil {
+					return
 				}
 			}
-		} else {
-			if err := t.setByField(ctx, newVal, ct); err != nil {
-				return err
+			return
+		}); err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			if e.keyed {
+				current.SetMapIndex(e.key, reflect.Value{})
 			}
 		}
-		current.SetMapIndex(key, newVal)
+
+		for _, e := range entries {
+			current.SetMapIndex(e.newKey, e.value)
+		}
+		return nil
+	}
+
+	for _, key := range keys {
+		e, err := tr.setByMapEntry(ctx, current, key, p, ct)
+		if err != nil {
+			return err
+		}
+
+		// remove current map key as it may have been changed
+		if e.keyed {
+			current.SetMapIndex(e.key, reflect.Value{})
+		}
+		current.SetMapIndex(e.newKey, e.value)
 	}
 
 	return nil
 }
 
-func (t *Transformer) setByIterable(ctx context.Context, current reflect.Value, ct *cTag) (err error) {
-	for i := 0; i < current.Len(); i++ {
-		if err = t.setByField(ctx, current.Index(i), ct); err != nil {
+// setByMapEntry transforms the key and value of the map entry without modifying the map.
+func (tr *transform) setByMapEntry(ctx context.Context, current, key reflect.Value, p fieldPath, ct *cTag) (e mapEntry, err error) {
+	kp := p.key(key)
+	e.key, e.newKey = key, key
+	if tr.filtered(kp) {
+		// written back as is
+		e.value = current.MapIndex(key)
+		return
+	}
+
+	if err = tr.checkCanceled(ctx, kp); err != nil {
+		return
+	}
+
+	e.value = reflect.New(current.Type().Elem()).Elem()
+	e.value.Set(current.MapIndex(key))
+	if ct != nil && ct.typeof == typeKeys && ct.keys != nil {
+		e.keyed = true
+		e.newKey = reflect.New(current.Type().Key()).Elem()
+		e.newKey.Set(key)
+		// handle map key
+		if err = tr.setByField(ctx, e.newKey, kp, ct.keys); err != nil {
 			return
 		}
+
+		// can be nil when just keys being validated
+		if ct.next != nil {
+			err = tr.setByField(ctx, e.value, kp, ct.next)
+		}
+		return
 	}
 
+	err = tr.setByField(ctx, e.value, kp, ct)
 	return
 }
 
-func (t *Transformer) setByStruct(ctx context.Context, parent, current reflect.Value, typ reflect.Type) (err error) {
-	cs, ok := t.cCache.Get(typ)
+func (tr *transform) setByIterable(ctx context.Context, current reflect.Value, p fieldPath, ct *cTag) error {
+	if l := current.Len(); tr.workers != nil && l > 1 {
+		return tr.parallel(l, func(w *transform, start, end int) error {
+			return w.setByRange(ctx, current, p, ct, start, end)
+		})
+	}
+
+	return tr.setByRange(ctx, current, p, ct, 0, current.Len())
+}
+
+// setByRange transforms the elements from start to end of a slice or array.
+func (tr *transform) setByRange(ctx context.Context, current reflect.Value, p fieldPath, ct *cTag, start, end int) (err error) {
+	for i := start; i < end; i++ {
+		ip := p.index(i)
+		if tr.filtered(ip) {
+			continue
+		}
+
+		if err = tr.checkCanceled(ctx, ip); err != nil {
+			return
+		}
+
+		if err = tr.setByField(ctx, current.Index(i), ip, ct); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// setByRules transforms the values of m according to rules in the order of their keys.
+func (tr *transform) setByRules(ctx context.Context, m map[string]interface{}, rules map[string]interface{}, p fieldPath) error {
+	keys := make([]string, 0, len(rules))
+	for key := range rules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		v, ok := m[key]
+		if !ok {
+			continue
+		}
+
+		kp := p.entry(key)
+		if err := tr.checkCanceled(ctx, kp); err != nil {
+			return err
+		}
+
+		switch rule := rules[key].(type) {
+		case string:
+			if len(rule) == 0 || rule == ignoreTag {
+				continue
+			}
+
+			ctag, err := tr.t.extractTagCache(rule)
+			if err != nil {
+				return err
+			}
+
+			if err = tr.setByField(ctx, reflect.ValueOf(&v).Elem(), kp, ctag); err != nil {
+				return err
+			}
+			m[key] = v
+		case map[string]interface{}:
+			if v == nil {
+				continue
+			}
+
+			nested, ok := v.(map[string]interface{})
+			if !ok {
+				return &ErrInvalidMapRule{ns: kp.ns(), value: reflect.TypeOf(v)}
+			}
+
+			if err := tr.setByRules(ctx, nested, rule, kp); err != nil {
+				return err
+			}
+		default:
+			return &ErrInvalidMapRule{ns: kp.ns(), rule: reflect.TypeOf(rule)}
+		}
+	}
+
+	return nil
+}
+
+func (tr *transform) setByStruct(ctx context.Context, parent, current reflect.Value, typ reflect.Type, p fieldPath) (err error) {
+	cs, ok := tr.t.cCache.Get(structKey{typ: typ, groups: tr.groupKey})
 	if !ok {
-		if cs, err = t.extractStructCache(current); err != nil {
+		if cs, err = tr.t.extractStructCache(current, tr.groups); err != nil {
+			return
+		}
+	}
+
+	if cs.generated && tr.generated && current.CanAddr() && current.CanInterface() {
+		if handled, err := current.Addr().Interface().(Generated).MoldTag(ctx, tr.t.tagName); handled {
+			return err
+		}
+	}
+
+	if p.empty() && len(cs.name) != 0 {
+		p = p.root(cs.name)
+	}
+
+	if cs.moldable && tr.t.moldFirst {
+		if err = tr.mold(ctx, parent, current, p); err != nil {
 			return
 		}
 	}
@@ -317,21 +1181,35 @@ func (t *Transformer) setByStruct(ctx context.Context, parent, current reflect.V
 	// run is struct has a corresponding struct level transformation
 	if cs.fn != nil {
 		if err = cs.fn(ctx, structLevel{
-			transformer: t,
+			transformer: tr.t,
 			parent:      parent,
 			current:     current,
 		}); err != nil {
-			return
+			if err = tr.fail(p, nil, err); err != nil {
+				return
+			}
 		}
 	}
 
 	var f *cField
 	for i := 0; i < len(cs.fields); i++ {
 		f = cs.fields[i]
-		if err = t.setByField(ctx, current.Field(f.idx), f.cTags); err != nil {
+		fp := p.field(f, current)
+		if tr.filtered(fp) {
+			continue
+		}
+
+		if err = tr.checkCanceled(ctx, fp); err != nil {
+			return
+		}
+
+		if err = tr.setByField(ctx, current.Field(f.idx), fp, f.cTags); err != nil {
 			return
 		}
 	}
 
-	return nil
+	if cs.moldable && !tr.t.moldFirst {
+		err = tr.mold(ctx, parent, current, p)
+	}
+	return
 }
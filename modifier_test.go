@@ -3,12 +3,15 @@ package modifier
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	. "github.com/pchchv/go-assert"
+	"golang.org/x/text/language"
 )
 
 func TestBadValues(t *testing.T) {
@@ -27,7 +30,7 @@ func TestBadValues(t *testing.T) {
 
 	err = tform.Struct(context.Background(), tt)
 	NotEqual(t, err, nil)
-	Equal(t, err.Error(), "mold: Struct(non-pointer mold.Test)")
+	Equal(t, err.Error(), "mold: Struct(non-pointer modifier.Test)")
 
 	err = tform.Struct(context.Background(), nil)
 	NotEqual(t, err, nil)
@@ -51,7 +54,7 @@ func TestBadValues(t *testing.T) {
 	var tst *Test
 	err = tform.Struct(context.Background(), tst)
 	NotEqual(t, err, nil)
-	Equal(t, err.Error(), "mold: Struct(nil *mold.Test)")
+	Equal(t, err.Error(), "mold: Struct(nil *modifier.Test)")
 
 	var tm *time.Time
 	err = tform.Field(context.Background(), tm, "blah")
@@ -536,7 +539,7 @@ func TestBasicTransform(t *testing.T) {
 	val := reflect.ValueOf(tt)
 	// trigger a wait in struct parsing
 	for i := 0; i < 3; i++ {
-		_, err := set.extractStructCache(val)
+		_, err := set.extractStructCache(val, nil)
 		Equal(t, err, nil)
 	}
 	err := set.Struct(context.Background(), &tt)
@@ -644,3 +647,1612 @@ func TestBasicTransform(t *testing.T) {
 	err = set.Field(context.Background(), &tt6.String, "dummy")
 	Equal(t, err, nil)
 }
+
+func TestCollectErrors(t *testing.T) {
+	type Address struct {
+		Name  string `s:"trim"`
+		Phone string `s:"phone"`
+	}
+
+	type User struct {
+		Name    string            `s:"trim,bad=x"`
+		Address []Address         `s:"dive"`
+		Misc    map[string]string `s:"dive,bad"`
+	}
+
+	errBad := errors.New("bad value")
+	set := New()
+	set.SetTagName("s")
+	set.SetCollectErrors(true)
+	set.Register("trim", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+		return nil
+	})
+	set.Register("bad", func(ctx context.Context, fl FieldLevel) error {
+		return errBad
+	})
+	set.Register("phone", func(ctx context.Context, fl FieldLevel) error {
+		if fl.Field().String() == "" {
+			return errBad
+		}
+		return nil
+	})
+
+	u := User{
+		Name:    " name ",
+		Address: []Address{{Name: " a ", Phone: "1"}, {Name: " b "}},
+		Misc:    map[string]string{"k": "v"},
+	}
+
+	err := set.Struct(context.Background(), &u)
+	NotEqual(t, err, nil)
+
+	errs, ok := err.(TransformErrors)
+	Equal(t, ok, true)
+	Equal(t, len(errs), 3)
+	Equal(t, errs[0].Namespace(), "User.Name")
+	Equal(t, errs[0].Tag(), "bad")
+	Equal(t, errs[0].Param(), "x")
+	Equal(t, errors.Is(errs[0], errBad), true)
+	Equal(t, errs[1].Namespace(), "User.Address[1].Phone")
+	Equal(t, errs[1].Tag(), "phone")
+	Equal(t, errs[2].Namespace(), "User.Misc[k]")
+	Equal(t, errs[1].Error(), "transformation 'phone' of 'User.Address[1].Phone' failed: bad value")
+
+	// remaining fields are still transformed
+	Equal(t, u.Name, "name")
+	Equal(t, u.Address[0].Name, "a")
+	Equal(t, u.Address[1].Name, "b")
+
+	s := " value "
+	err = set.Field(context.Background(), &s, "bad,trim")
+	NotEqual(t, err, nil)
+	Equal(t, len(err.(TransformErrors)), 1)
+	Equal(t, s, " value ")
+
+	err = set.Field(context.Background(), &s, "trim")
+	Equal(t, err, nil)
+
+	set.SetCollectErrors(false)
+	err = set.Struct(context.Background(), &u)
+	Equal(t, err, errBad)
+}
+
+func TestFieldLevelMetadata(t *testing.T) {
+	type Address struct {
+		Phone string `s:"record" json:"phone"`
+	}
+
+	type User struct {
+		Name    string    `s:"record" json:"name" db:"user_name"`
+		Address []Address `s:"dive" json:"address"`
+		Tags    []string  `s:"dive,record"`
+	}
+
+	type record struct {
+		fieldName       string
+		structFieldName string
+		ns              string
+		structNs        string
+		dbTag           string
+		hasField        bool
+		top             reflect.Value
+	}
+
+	var records []record
+	set := New()
+	set.SetTagName("s")
+	set.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	set.Register("record", func(ctx context.Context, fl FieldLevel) error {
+		fld, ok := fl.StructField()
+		records = append(records, record{
+			fieldName:       fl.FieldName(),
+			structFieldName: fl.StructFieldName(),
+			ns:              fl.Namespace(),
+			structNs:        fl.StructNamespace(),
+			dbTag:           fld.Tag.Get("db"),
+			hasField:        ok,
+			top:             fl.Top(),
+		})
+		return nil
+	})
+
+	u := User{Address: []Address{{}, {}}, Tags: []string{"a"}}
+	err := set.Struct(context.Background(), &u)
+	Equal(t, err, nil)
+	Equal(t, len(records), 4)
+	Equal(t, records[0], record{
+		fieldName:       "name",
+		structFieldName: "Name",
+		ns:              "User.name",
+		structNs:        "User.Name",
+		dbTag:           "user_name",
+		hasField:        true,
+		top:             records[0].top,
+	})
+	Equal(t, records[0].top.Interface(), &u)
+	Equal(t, records[1].ns, "User.address[0].phone")
+	Equal(t, records[1].structNs, "User.Address[0].Phone")
+	Equal(t, records[1].fieldName, "phone")
+	Equal(t, records[2].ns, "User.address[1].phone")
+	Equal(t, records[3].fieldName, "Tags[0]")
+	Equal(t, records[3].structFieldName, "Tags[0]")
+	Equal(t, records[3].ns, "User.Tags[0]")
+
+	records = records[:0]
+	var s string
+	err = set.Field(context.Background(), &s, "record")
+	Equal(t, err, nil)
+	Equal(t, len(records), 1)
+	Equal(t, records[0].ns, "")
+	Equal(t, records[0].hasField, false)
+	Equal(t, records[0].top.Interface(), &s)
+}
+
+func TestGetStructFieldOK(t *testing.T) {
+	type Inner struct {
+		Name string
+	}
+
+	type Test struct {
+		FirstName   string
+		LastName    string
+		DisplayName string `s:"display"`
+		Country     *string
+		Currency    string `s:"currency"`
+		Inner       *Inner
+		Iface       interface{}
+		Slice       []Inner
+		Map         map[int]string
+	}
+
+	set := New()
+	set.SetTagName("s")
+	set.Register("display", func(ctx context.Context, fl FieldLevel) error {
+		first, kind, ok := fl.GetStructFieldOK("FirstName")
+		Equal(t, ok, true)
+		Equal(t, kind, reflect.String)
+		last, _, _ := fl.GetStructFieldOK("LastName")
+		fl.Field().SetString(first.String() + " " + last.String())
+		return nil
+	})
+	set.Register("currency", func(ctx context.Context, fl FieldLevel) error {
+		if country, _, ok := fl.GetStructFieldOK("Country"); ok && country.String() == "DE" {
+			fl.Field().SetString("EUR")
+		}
+		return nil
+	})
+
+	country := "DE"
+	tt := Test{
+		FirstName: "Joey",
+		LastName:  "Bloggs",
+		Country:   &country,
+		Inner:     &Inner{Name: "inner"},
+		Iface:     Inner{Name: "iface"},
+		Slice:     []Inner{{Name: "first"}},
+		Map:       map[int]string{3: "three"},
+	}
+	err := set.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt.DisplayName, "Joey Bloggs")
+	Equal(t, tt.Currency, "EUR")
+
+	tt2 := Test{}
+	err = set.Struct(context.Background(), &tt2)
+	Equal(t, err, nil)
+	Equal(t, tt2.Currency, "")
+
+	tests := []struct {
+		ns       string
+		expected interface{}
+		ok       bool
+	}{
+		{ns: "Inner.Name", expected: "inner", ok: true},
+		{ns: "Iface.Name", expected: "iface", ok: true},
+		{ns: "Slice[0].Name", expected: "first", ok: true},
+		{ns: "Map[3]", expected: "three", ok: true},
+		{ns: "Slice[1].Name"},
+		{ns: "Map[4]"},
+		{ns: "Map[a]"},
+		{ns: "Unknown"},
+		{ns: "Inner.Unknown"},
+	}
+
+	fl := fieldLevel{tr: &transform{t: set}}
+	for _, tc := range tests {
+		v, _, ok := fl.GetStructFieldOKAdvanced(reflect.ValueOf(&tt), tc.ns)
+		Equal(t, ok, tc.ok)
+		if ok {
+			Equal(t, v.Interface(), tc.expected)
+		}
+	}
+
+	_, _, ok := fl.GetStructFieldOK("FirstName")
+	Equal(t, ok, false)
+}
+
+func TestConditionalTags(t *testing.T) {
+	type Test struct {
+		Country  string
+		Enabled  bool
+		Empty    string  `s:"omitempty,set"`
+		NotEmpty string  `s:"omitempty,set"`
+		NilPtr   *string `s:"omitnil,set"`
+		Ptr      *string `s:"omitnil,set"`
+		If       string  `s:"if=Country:DE,set"`
+		IfNot    string  `s:"if=Country:FR,set"`
+		IfBool   string  `s:"if=Enabled,set"`
+		Unless   string  `s:"unless=Country:DE,set"`
+		UnlessNo string  `s:"unless=Country:FR,set"`
+	}
+
+	set := New()
+	set.SetTagName("s")
+	set.Register("set", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString("set")
+		return nil
+	})
+
+	str := "value"
+	tt := Test{Country: "DE", NotEmpty: "value", Ptr: &str}
+	err := set.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt.Empty, "")
+	Equal(t, tt.NotEmpty, "set")
+	Equal(t, tt.NilPtr, nil)
+	Equal(t, *tt.Ptr, "set")
+	Equal(t, tt.If, "set")
+	Equal(t, tt.IfNot, "")
+	Equal(t, tt.IfBool, "")
+	Equal(t, tt.Unless, "")
+	Equal(t, tt.UnlessNo, "set")
+
+	tt = Test{Enabled: true}
+	err = set.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt.IfBool, "set")
+
+	var s string
+	err = set.Field(context.Background(), &s, "omitempty,set")
+	Equal(t, err, nil)
+	Equal(t, s, "")
+
+	// guards without a struct never hold
+	err = set.Field(context.Background(), &s, "if=Country,set")
+	Equal(t, err, nil)
+	Equal(t, s, "")
+
+	err = set.Field(context.Background(), &s, "unless=Country,set")
+	Equal(t, err, nil)
+	Equal(t, s, "set")
+
+	err = set.Field(context.Background(), &s, "if")
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "invalid tag 'if' found on field ")
+
+	PanicMatches(t, func() {
+		set.Register("omitempty", func(ctx context.Context, fl FieldLevel) error { return nil })
+	}, "Tag 'omitempty' either contains restricted characters or is the same as a restricted tag needed for normal operation")
+}
+
+func TestOrTags(t *testing.T) {
+	errNotNumber := errors.New("not a number")
+	set := New()
+	set.Register("number", func(ctx context.Context, fl FieldLevel) error {
+		s := strings.TrimPrefix(fl.Field().String(), "+")
+		fl.Field().SetString(s)
+		for _, r := range s {
+			if r < '0' || r > '9' {
+				return errNotNumber
+			}
+		}
+		return nil
+	})
+	set.Register("skip", func(ctx context.Context, fl FieldLevel) error {
+		return ErrNotApplicable
+	})
+	set.Register("set", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(fl.Param())
+		return nil
+	})
+
+	s := "+123"
+	err := set.Field(context.Background(), &s, "number|set=invalid")
+	Equal(t, err, nil)
+	Equal(t, s, "123")
+
+	// the value is restored before the next alternative runs
+	s = "+12a"
+	err = set.Field(context.Background(), &s, "number|set=invalid")
+	Equal(t, err, nil)
+	Equal(t, s, "invalid")
+
+	s = "+12a"
+	err = set.Field(context.Background(), &s, "skip|number")
+	Equal(t, err, errNotNumber)
+	Equal(t, s, "+12a")
+
+	s = "value"
+	err = set.Field(context.Background(), &s, "skip|skip")
+	Equal(t, err, nil)
+	Equal(t, s, "value")
+
+	err = set.Field(context.Background(), &s, "skip,set=a0x7Cb")
+	Equal(t, err, nil)
+	Equal(t, s, "a|b")
+
+	var iface interface{} = "+12a"
+	err = set.Field(context.Background(), &iface, "number|set=invalid")
+	Equal(t, err, nil)
+	Equal(t, iface, "invalid")
+
+	type Test struct {
+		String string `mold:"number|set=invalid,set=done"`
+	}
+
+	tt := Test{String: "x"}
+	err = set.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt.String, "done")
+
+	err = set.Field(context.Background(), &s, "number|dive")
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "invalid tag 'number|dive' found on field ")
+
+	err = set.Field(context.Background(), &s, "number|undefined")
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "unregistered/undefined transformation 'undefined' found on field")
+
+	err = set.Field(context.Background(), &s, "number|")
+	NotEqual(t, err, nil)
+}
+
+func TestStructCopy(t *testing.T) {
+	type Inner struct {
+		String string `s:"set"`
+	}
+
+	type Test struct {
+		String  string `s:"set"`
+		Ptr     *Inner
+		Slice   []Inner           `s:"dive"`
+		Array   [1]Inner          `s:"dive"`
+		Map     map[string]string `s:"dive,set"`
+		Iface   interface{}
+		private string
+	}
+
+	set := New()
+	set.SetTagName("s")
+	set.Register("set", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString("set")
+		return nil
+	})
+
+	tt := &Test{
+		String:  "orig",
+		Ptr:     &Inner{String: "orig"},
+		Slice:   []Inner{{String: "orig"}},
+		Array:   [1]Inner{{String: "orig"}},
+		Map:     map[string]string{"key": "orig"},
+		Iface:   &Inner{String: "orig"},
+		private: "private",
+	}
+
+	cp, err := set.StructCopy(context.Background(), tt)
+	Equal(t, err, nil)
+
+	tc := cp.(*Test)
+	Equal(t, tc.String, "set")
+	Equal(t, tc.Ptr.String, "set")
+	Equal(t, tc.Slice[0].String, "set")
+	Equal(t, tc.Array[0].String, "set")
+	Equal(t, tc.Map["key"], "set")
+	Equal(t, tc.Iface.(*Inner).String, "set")
+	Equal(t, tc.private, "private")
+
+	Equal(t, tt.String, "orig")
+	Equal(t, tt.Ptr.String, "orig")
+	Equal(t, tt.Slice[0].String, "orig")
+	Equal(t, tt.Array[0].String, "orig")
+	Equal(t, tt.Map["key"], "orig")
+	Equal(t, tt.Iface.(*Inner).String, "orig")
+
+	value, err := Clone(context.Background(), set, Test{String: "orig", Slice: []Inner{{String: "orig"}}})
+	Equal(t, err, nil)
+	Equal(t, value.String, "set")
+	Equal(t, value.Slice[0].String, "set")
+
+	ptr, err := Clone(context.Background(), set, tt)
+	Equal(t, err, nil)
+	Equal(t, ptr.String, "set")
+	Equal(t, tt.String, "orig")
+
+	// maps and slices containing themselves
+	m := map[string]interface{}{}
+	m["self"] = m
+	sl := []interface{}{nil, "orig"}
+	sl[0] = sl
+	cyclic, err := Clone(context.Background(), set, Test{String: "orig", Iface: []interface{}{m, sl}})
+	Equal(t, err, nil)
+	Equal(t, cyclic.String, "set")
+	mc := cyclic.Iface.([]interface{})[0].(map[string]interface{})
+	Equal(t, reflect.ValueOf(mc["self"]).Pointer(), reflect.ValueOf(mc).Pointer())
+	NotEqual(t, reflect.ValueOf(mc).Pointer(), reflect.ValueOf(m).Pointer())
+	sc := cyclic.Iface.([]interface{})[1].([]interface{})
+	Equal(t, reflect.ValueOf(sc[0]).Pointer(), reflect.ValueOf(sc).Pointer())
+	NotEqual(t, reflect.ValueOf(sc).Pointer(), reflect.ValueOf(sl).Pointer())
+
+	_, err = set.StructCopy(context.Background(), nil)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "mold: StructCopy(nil)")
+
+	_, err = Clone(context.Background(), set, (*Test)(nil))
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "mold: StructCopy(nil *modifier.Test)")
+
+	_, err = Clone(context.Background(), set, 1)
+	NotEqual(t, err, nil)
+}
+
+func TestApply(t *testing.T) {
+	type Test struct {
+		String string `s:"trim"`
+	}
+
+	set := New()
+	set.SetTagName("s")
+	set.Register("trim", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+		return nil
+	})
+
+	s, err := Apply(context.Background(), set, "  value  ", "trim")
+	Equal(t, err, nil)
+	Equal(t, s, "value")
+
+	var iface interface{} = " value "
+	iface, err = Apply(context.Background(), set, iface, "trim")
+	Equal(t, err, nil)
+	Equal(t, iface, "value")
+
+	s, err = Apply(context.Background(), set, " value ", "undefined")
+	NotEqual(t, err, nil)
+	Equal(t, s, "")
+
+	orig := Test{String: " value "}
+	tt, err := ApplyStruct(context.Background(), set, orig)
+	Equal(t, err, nil)
+	Equal(t, tt.String, "value")
+	Equal(t, orig.String, " value ")
+
+	ptr, err := ApplyStruct(context.Background(), set, &orig)
+	Equal(t, err, nil)
+	Equal(t, ptr.String, "value")
+	Equal(t, orig.String, "value")
+
+	_, err = ApplyStruct(context.Background(), set, (*Test)(nil))
+	NotEqual(t, err, nil)
+
+	_, err = ApplyStruct(context.Background(), set, 1)
+	NotEqual(t, err, nil)
+}
+
+func TestContextCanceled(t *testing.T) {
+	type Test struct {
+		Name  string
+		Items []string          `s:"dive,count"`
+		Map   map[string]string `s:"dive,count"`
+	}
+
+	var count int
+	ctx, cancel := context.WithCancel(context.Background())
+	set := New()
+	set.SetTagName("s")
+	set.Register("count", func(ctx context.Context, fl FieldLevel) error {
+		count++
+		if count == 3 {
+			cancel()
+		}
+		return nil
+	})
+
+	tt := Test{Items: make([]string, 10)}
+	err := set.Struct(ctx, &tt)
+	NotEqual(t, err, nil)
+	Equal(t, errors.Is(err, context.Canceled), true)
+
+	var errCanceled *ErrCanceled
+	Equal(t, errors.As(err, &errCanceled), true)
+	Equal(t, errCanceled.Namespace(), "Test.Items[3]")
+	Equal(t, errCanceled.Processed(), 5)
+	Equal(t, count, 3)
+	Equal(t, err.Error(), "mold: transformation aborted at 'Test.Items[3]' after 5 values: context canceled")
+
+	m := map[string]string{"a": "", "b": ""}
+	err = set.Field(ctx, &m, "dive,count")
+	Equal(t, errors.Is(err, context.Canceled), true)
+	Equal(t, errors.As(err, &errCanceled), true)
+	Equal(t, errCanceled.Processed(), 0)
+
+	deadline, cancel2 := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel2()
+	err = set.Struct(deadline, &tt)
+	Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+
+	// uncancelable contexts are never checked
+	count = 0
+	err = set.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, count, 10)
+}
+
+func TestParallelism(t *testing.T) {
+	type Inner struct {
+		String string `s:"check"`
+	}
+
+	type Test struct {
+		Slice []Inner            `s:"dive"`
+		Map   map[string]string  `s:"dive,keys,check,endkeys,check"`
+		Inner map[int][]Inner    `s:"dive,dive"`
+		Ptrs  []*Inner           `s:"dive"`
+		Iface map[string]*string `s:"dive,check"`
+	}
+
+	errBad := errors.New("bad")
+	set := New()
+	set.SetTagName("s")
+	set.Register("check", func(ctx context.Context, fl FieldLevel) error {
+		if strings.HasPrefix(fl.Field().String(), "bad") {
+			return errBad
+		}
+		fl.Field().SetString(strings.ToUpper(fl.Field().String()))
+		return nil
+	})
+
+	newTest := func() Test {
+		tt := Test{
+			Slice: make([]Inner, 1000),
+			Map:   make(map[string]string),
+			Inner: make(map[int][]Inner),
+		}
+		for i := range tt.Slice {
+			tt.Slice[i].String = "s" + strconv.Itoa(i)
+			tt.Map["k"+strconv.Itoa(i)] = "v" + strconv.Itoa(i)
+			tt.Ptrs = append(tt.Ptrs, &Inner{String: "p"})
+		}
+		for i := 0; i < 10; i++ {
+			tt.Inner[i] = []Inner{{String: "a"}, {String: "b"}}
+		}
+		return tt
+	}
+
+	ctx := WithParallelism(context.Background(), 4)
+	tt := newTest()
+	err := set.Struct(ctx, &tt)
+	Equal(t, err, nil)
+	Equal(t, len(tt.Map), 1000)
+	for i := range tt.Slice {
+		Equal(t, tt.Slice[i].String, "S"+strconv.Itoa(i))
+		Equal(t, tt.Map["K"+strconv.Itoa(i)], "V"+strconv.Itoa(i))
+		Equal(t, tt.Ptrs[i].String, "P")
+	}
+	for i := 0; i < 10; i++ {
+		Equal(t, tt.Inner[i][0].String, "A")
+		Equal(t, tt.Inner[i][1].String, "B")
+	}
+
+	// errors are reported as if the elements were processed in order
+	for i := 0; i < 10; i++ {
+		tt = newTest()
+		tt.Slice[900].String = "bad900"
+		tt.Slice[10].String = "bad10"
+		tt.Slice[500].String = "bad500"
+
+		set.SetCollectErrors(true)
+		err = set.Struct(ctx, &tt)
+		errs, ok := err.(TransformErrors)
+		Equal(t, ok, true)
+		Equal(t, len(errs), 3)
+		Equal(t, errs[0].Namespace(), "Test.Slice[10].String")
+		Equal(t, errs[1].Namespace(), "Test.Slice[500].String")
+		Equal(t, errs[2].Namespace(), "Test.Slice[900].String")
+
+		set.SetCollectErrors(false)
+		tt = newTest()
+		tt.Slice[900].String = "bad900"
+		tt.Slice[10].String = "bad10"
+		err = set.Struct(ctx, &tt)
+		Equal(t, err, errBad)
+	}
+
+	// panics are passed on to the caller
+	set.Register("panic", func(ctx context.Context, fl FieldLevel) error {
+		if fl.Field().String() == "panic" {
+			panic("panic")
+		}
+		return nil
+	})
+	s := []string{"", "", "", "panic"}
+	PanicMatches(t, func() { _ = set.Field(ctx, &s, "dive,panic") }, "panic")
+}
+
+type generatedTest struct {
+	String string `mold:"upper"`
+	calls  int
+}
+
+func (g *generatedTest) MoldTag(_ context.Context, tagName string) (bool, error) {
+	if tagName != "mold" {
+		return false, nil
+	}
+
+	g.calls++
+	g.String = strings.ToUpper(g.String) + "!"
+	return true, nil
+}
+
+func TestGenerated(t *testing.T) {
+	type Test struct {
+		Inner  generatedTest
+		String string `mold:"upper"`
+	}
+
+	tform := New()
+	tform.Register("upper", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.ToUpper(fl.Field().String()))
+		return nil
+	})
+
+	// generated code is opt-in
+	gt := generatedTest{String: "z"}
+	err := tform.Struct(context.Background(), &gt)
+	Equal(t, err, nil)
+	Equal(t, gt.String, "Z")
+	Equal(t, gt.calls, 0)
+
+	tform.SetUseGenerated(true)
+	tt := Test{Inner: generatedTest{String: "a"}, String: "b"}
+	err = tform.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt.Inner.String, "A!")
+	Equal(t, tt.Inner.calls, 1)
+	Equal(t, tt.String, "B")
+
+	gt = generatedTest{String: "c"}
+	err = tform.Struct(context.Background(), &gt)
+	Equal(t, err, nil)
+	Equal(t, gt.String, "C!")
+
+	// the method is only called for the tag name it handles
+	other := New()
+	other.SetTagName("other")
+	other.SetUseGenerated(true)
+	gt = generatedTest{String: "d"}
+	err = other.Struct(context.Background(), &gt)
+	Equal(t, err, nil)
+	Equal(t, gt.String, "d")
+	Equal(t, gt.calls, 0)
+
+	tform.SetUseGenerated(false)
+	gt = generatedTest{String: "e"}
+	err = tform.Struct(context.Background(), &gt)
+	Equal(t, err, nil)
+	Equal(t, gt.String, "E")
+
+	// generated code knows nothing about collected errors
+	tform.SetUseGenerated(true)
+	tform.SetCollectErrors(true)
+	gt = generatedTest{String: "f"}
+	err = tform.Struct(context.Background(), &gt)
+	Equal(t, err, nil)
+	Equal(t, gt.String, "F")
+}
+
+func TestValidateTags(t *testing.T) {
+	tform := New()
+	tform.Register("trim", func(ctx context.Context, fl FieldLevel) error { return nil })
+	tform.RegisterAlias("clean", "trim,trim")
+
+	Equal(t, tform.ValidateTags("Name", ""), nil)
+	Equal(t, tform.ValidateTags("Name", "-"), nil)
+	Equal(t, tform.ValidateTags("Name", "trim,clean"), nil)
+	Equal(t, tform.ValidateTags("Name", "dive,keys,trim,endkeys,trim"), nil)
+
+	err := tform.ValidateTags("Name", "trmi")
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "unregistered/undefined transformation 'trmi' found on field Name")
+
+	err = tform.ValidateTags("Name", "trim,,trim")
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "invalid tag '' found on field Name")
+
+	err = tform.ValidateTags("Name", "keys,trim,endkeys")
+	Equal(t, err, ErrInvalidKeysTag)
+
+	// aliases which aren't registered yet
+	aliases := map[string]string{"tidy": "clean,strip", "strip": "trim"}
+	Equal(t, tform.ValidateTagsWithAliases("Name", "tidy", aliases), nil)
+	NotEqual(t, tform.ValidateTags("Name", "tidy"), nil)
+
+	Equal(t, tform.ValidateAlias("tidy", "trim"), nil)
+	err = tform.ValidateAlias("dive", "trim")
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "Alias 'dive' either contains restricted characters or is the same as a restricted tag needed for normal operation")
+	NotEqual(t, tform.ValidateAlias("", "trim"), nil)
+	NotEqual(t, tform.ValidateAlias("tidy", ""), nil)
+
+	// aliases referring to themselves
+	aliases = map[string]string{"a": "b", "b": "trim,a"}
+	err = tform.ValidateTagsWithAliases("Name", "trim,a", aliases)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "alias 'a' refers to itself through 'a -> b -> a' on field Name")
+
+	err = tform.ValidateTagsWithAliases("Name", "a", map[string]string{"a": "a,trim"})
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "alias 'a' refers to itself through 'a -> a' on field Name")
+
+	// an alias may be used repeatedly
+	Equal(t, tform.ValidateTagsWithAliases("Name", "strip,strip,dive,strip", map[string]string{"strip": "trim"}), nil)
+}
+
+func TestCompile(t *testing.T) {
+	type Inner struct {
+		Bad    string `mold:"trmi"`
+		String string `mold:"trim"`
+	}
+
+	type Test struct {
+		Bad      string `mold:"trim,,trim"`
+		Inner    Inner
+		Inners   []*Inner
+		Map      map[string]Inner
+		Ignored  Inner `mold:"-"`
+		Time     time.Time
+		Keys     map[string]string `mold:"keys,trim,endkeys"`
+		Interval time.Duration     `mold:"trim"`
+	}
+
+	type Valid struct {
+		String string `mold:"trim"`
+		Inners []struct {
+			String string `mold:"trim"`
+		} `mold:"dive"`
+	}
+
+	tform := New()
+	tform.Register("trim", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+		return nil
+	})
+
+	err := tform.Compile(&Test{}, reflect.TypeOf(Valid{}), nil)
+	NotEqual(t, err, nil)
+	errs, ok := err.(CompileErrors)
+	Equal(t, ok, true)
+	Equal(t, len(errs), 3)
+	Equal(t, errs[0].Namespace(), "Test.Bad")
+	Equal(t, errs[0].Error(), "invalid tags of 'Test.Bad': invalid tag '' found on field Bad")
+	Equal(t, errs[1].Namespace(), "Test.Keys")
+	Equal(t, errors.Is(errs[1], ErrInvalidKeysTag), true)
+	Equal(t, errs[2].Namespace(), "Test.Inner.Bad")
+	var undefined *ErrUndefinedTag
+	Equal(t, errors.As(errs[2], &undefined), true)
+	Equal(t, err.Error(), "invalid tags of 'Test.Bad': invalid tag '' found on field Bad\n"+
+		"invalid tags of 'Test.Keys': '"+keysTag+"' tag must be immediately preceeded by the '"+diveTag+"' tag\n"+
+		"invalid tags of 'Test.Inner.Bad': unregistered/undefined transformation 'trmi' found on field Bad")
+
+	// valid types are cached
+	_, ok = tform.cCache.Get(structKey{typ: reflect.TypeOf(Valid{})})
+	Equal(t, ok, true)
+	_, ok = tform.cCache.Get(structKey{typ: reflect.TypeOf(Valid{}).Field(1).Type.Elem()})
+	Equal(t, ok, true)
+	_, ok = tform.cCache.Get(structKey{typ: reflect.TypeOf(Test{})})
+	Equal(t, ok, false)
+
+	Equal(t, tform.Compile(Valid{}), nil)
+	v := Valid{String: " a "}
+	Equal(t, tform.Struct(context.Background(), &v), nil)
+	Equal(t, v.String, "a")
+}
+
+func TestMapRules(t *testing.T) {
+	tform := New()
+	tform.Register("trim", func(ctx context.Context, fl FieldLevel) error {
+		if fl.Field().Kind() == reflect.String {
+			fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+		}
+		return nil
+	})
+	tform.Register("fail", func(ctx context.Context, fl FieldLevel) error {
+		return errors.New("failed at " + fl.Namespace())
+	})
+
+	m := map[string]interface{}{
+		"name":    " joe ",
+		"age":     42,
+		"tags":    []interface{}{" a ", " b "},
+		"labels":  map[string]string{"k": " v "},
+		"address": map[string]interface{}{"city": " berlin ", "zip": " 10115 "},
+		"none":    nil,
+		"other":   " other ",
+	}
+	err := tform.Map(context.Background(), m, map[string]interface{}{
+		"name":    "trim",
+		"age":     "trim",
+		"tags":    "dive,trim",
+		"labels":  "dive,trim",
+		"address": map[string]interface{}{"city": "trim", "missing": "trim"},
+		"none":    map[string]interface{}{"city": "trim"},
+		"missing": "trim",
+		"other":   "-",
+	})
+	Equal(t, err, nil)
+	Equal(t, m["name"], "joe")
+	Equal(t, m["age"], 42)
+	Equal(t, m["tags"], []interface{}{"a", "b"})
+	Equal(t, m["labels"], map[string]string{"k": "v"})
+	Equal(t, m["address"], map[string]interface{}{"city": "berlin", "zip": " 10115 "})
+	Equal(t, m["other"], " other ")
+	_, ok := m["missing"]
+	Equal(t, ok, false)
+
+	err = tform.Map(context.Background(), m, map[string]interface{}{"address": map[string]interface{}{"city": "fail"}})
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "failed at address.city")
+
+	err = tform.Map(context.Background(), m, map[string]interface{}{"name": "trmi"})
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "unregistered/undefined transformation 'trmi' found on field")
+
+	err = tform.Map(context.Background(), m, map[string]interface{}{"name": map[string]interface{}{"first": "trim"}})
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "mold: nested rules for 'name' of type string instead of map[string]interface {}")
+
+	err = tform.Map(context.Background(), m, map[string]interface{}{"address": map[string]interface{}{"city": 1}})
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "mold: invalid rule for 'address.city' of type int instead of string or map[string]interface {}")
+
+	tform.SetCollectErrors(true)
+	err = tform.Map(context.Background(), m, map[string]interface{}{"name": "fail", "address": map[string]interface{}{"zip": "fail"}})
+	errs, ok := err.(TransformErrors)
+	Equal(t, ok, true)
+	Equal(t, len(errs), 2)
+	Equal(t, errs[0].Namespace(), "address.zip")
+	Equal(t, errs[1].Namespace(), "name")
+}
+
+func TestStructRules(t *testing.T) {
+	type Base struct {
+		ID string
+	}
+
+	type Address struct {
+		City string `mold:"trim"`
+		Zip  string
+	}
+
+	type Test struct {
+		Base
+		Name      string `mold:"trim"`
+		Email     string `mold:"trim"`
+		Ignored   string `mold:"-"`
+		Address   Address
+		Addresses []*Address `mold:"dive"`
+		private   string
+	}
+
+	tform := New()
+	tform.Register("trim", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+		return nil
+	})
+	tform.Register("lcase", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.ToLower(fl.Field().String()))
+		return nil
+	})
+	tform.RegisterStructRules(map[string]string{
+		"ID":           "trim",
+		"Name":         "lcase",
+		"Email":        "+lcase",
+		"Ignored":      "+trim",
+		"Address.City": "+lcase",
+		"Address.Zip":  "trim",
+	}, &Test{})
+
+	tt := Test{
+		Base:      Base{ID: " ID "},
+		Name:      " Name ",
+		Email:     " Email ",
+		Ignored:   " Ignored ",
+		Address:   Address{City: " City ", Zip: " 123 "},
+		Addresses: []*Address{{City: " Other "}},
+		private:   " private ",
+	}
+	err := tform.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt.ID, "ID")
+	Equal(t, tt.Name, " name ")
+	Equal(t, tt.Email, "email")
+	Equal(t, tt.Ignored, "Ignored")
+	Equal(t, tt.Address.City, "city")
+	Equal(t, tt.Address.Zip, "123")
+	// rules of nested fields are registered for their struct type
+	Equal(t, tt.Addresses[0].City, "other")
+
+	PanicMatches(t, func() { tform.RegisterStructRules(map[string]string{"Missing": "trim"}, Test{}) }, "field 'Missing' of struct rule 'Missing' not found in modifier.Test")
+	PanicMatches(t, func() { tform.RegisterStructRules(map[string]string{"private": "trim"}, Test{}) }, "field 'private' of struct rule 'private' not found in modifier.Test")
+	PanicMatches(t, func() { tform.RegisterStructRules(map[string]string{"Name.First": "trim"}, Test{}) }, "field 'Name' of struct rule 'Name.First' in modifier.Test is no struct")
+	PanicMatches(t, func() { tform.RegisterStructRules(map[string]string{"Name": "trim"}, "") }, "struct rules cannot be registered for string")
+
+	// rules of nested fields conflict with the ones of other types for the same struct type
+	type Company struct {
+		Address *Address
+	}
+	tform.RegisterStructRules(map[string]string{"Address.City": "+lcase", "Address.Zip": "trim"}, Company{})
+	PanicMatches(t, func() { tform.RegisterStructRules(map[string]string{"Address.City": "ucase"}, Company{}) }, "struct rule 'Address.City' of modifier.Company conflicts with the rule '+lcase' registered for field 'City' of modifier.Address")
+	err = tform.ValidateStructRule(&Company{}, "Address.City", "ucase", nil)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "struct rule 'Address.City' of modifier.Company conflicts with the rule '+lcase' registered for field 'City' of modifier.Address")
+	Equal(t, tform.ValidateStructRule(&Company{}, "Address.City", "+lcase", nil), nil)
+
+	typ, name, err := StructRuleField(&Company{}, "Address.City")
+	Equal(t, err, nil)
+	Equal(t, typ == reflect.TypeOf(Address{}), true)
+	Equal(t, name, "City")
+	_, _, err = StructRuleField(Company{}, "Missing")
+	NotEqual(t, err, nil)
+
+	type Other struct {
+		Name string
+	}
+	Equal(t, tform.ValidateStructRule(&Other{}, "Name", "+clean", map[string]string{"clean": "trim"}), nil)
+	err = tform.ValidateStructRule(Test{}, "Missing", "trim", nil)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "field 'Missing' of struct rule 'Missing' not found in modifier.Test")
+	err = tform.ValidateStructRule(Other{}, "Name", "+trmi", nil)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "unregistered/undefined transformation 'trmi' found on field Name")
+	NotEqual(t, tform.ValidateStructRule("", "Name", "trim", nil), nil)
+}
+
+func TestStructChanges(t *testing.T) {
+	type Inner struct {
+		String string `mold:"trim"`
+	}
+
+	type Test struct {
+		Name    string   `mold:"trim,lower"`
+		Same    string   `mold:"trim"`
+		Ptr     *string  `mold:"default"`
+		Tags    []string `mold:"dive,trim"`
+		Inner   *Inner
+		Aliased string `mold:"clean"`
+	}
+
+	tform := New()
+	tform.Register("trim", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+		return nil
+	})
+	tform.Register("lower", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.ToLower(fl.Field().String()))
+		return nil
+	})
+	tform.Register("default", func(ctx context.Context, fl FieldLevel) error {
+		if fl.Field().Kind() == reflect.Ptr && fl.Field().IsNil() {
+			s := "default"
+			fl.Field().Set(reflect.ValueOf(&s))
+		}
+		return nil
+	})
+	tform.RegisterAlias("clean", "trim")
+
+	newTest := func() *Test {
+		return &Test{Name: " Joe ", Same: "same", Tags: []string{"a", " b "}, Inner: &Inner{String: " inner "}, Aliased: " a "}
+	}
+
+	expected := []Change{
+		{Namespace: "Test.Name", StructNamespace: "Test.Name", Tag: "trim", ActualTag: "trim", Before: " Joe ", After: "Joe"},
+		{Namespace: "Test.Name", StructNamespace: "Test.Name", Tag: "lower", ActualTag: "lower", Before: "Joe", After: "joe"},
+		{Namespace: "Test.Ptr", StructNamespace: "Test.Ptr", Tag: "default", ActualTag: "default", Before: nil, After: "default"},
+		{Namespace: "Test.Tags[1]", StructNamespace: "Test.Tags[1]", Tag: "trim", ActualTag: "trim", Before: " b ", After: "b"},
+		{Namespace: "Test.Inner.String", StructNamespace: "Test.Inner.String", Tag: "trim", ActualTag: "trim", Before: " inner ", After: "inner"},
+		{Namespace: "Test.Aliased", StructNamespace: "Test.Aliased", Tag: "clean", ActualTag: "trim", Before: " a ", After: "a"},
+	}
+
+	// a dry run leaves the value untouched
+	tt := newTest()
+	changes, err := tform.DryRun(context.Background(), tt)
+	Equal(t, err, nil)
+	Equal(t, changes, expected)
+	Equal(t, tt, newTest())
+
+	changes, err = tform.DryRun(context.Background(), *tt)
+	Equal(t, err, nil)
+	Equal(t, changes, expected)
+
+	changes, err = tform.StructChanges(context.Background(), tt)
+	Equal(t, err, nil)
+	Equal(t, changes, expected)
+	Equal(t, tt.Name, "joe")
+	Equal(t, *tt.Ptr, "default")
+	Equal(t, tt.Inner.String, "inner")
+
+	// nothing changes the second time
+	changes, err = tform.StructChanges(context.Background(), tt)
+	Equal(t, err, nil)
+	Equal(t, len(changes), 0)
+
+	tform.SetRedactChanges(true)
+	changes, err = tform.DryRun(context.Background(), newTest())
+	Equal(t, err, nil)
+	Equal(t, len(changes), len(expected))
+	Equal(t, changes[0].Before, redact(" Joe "))
+	Equal(t, changes[0].After, redact("Joe"))
+	Equal(t, strings.HasPrefix(changes[0].After.(string), "sha256:"), true)
+	Equal(t, len(changes[0].After.(string)), 71)
+	Equal(t, changes[1].Before, changes[0].After)
+
+	_, err = tform.DryRun(context.Background(), nil)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "mold: DryRun(nil)")
+}
+
+func TestMiddleware(t *testing.T) {
+	type Test struct {
+		Name   string   `mold:"trim,prefix=a"`
+		Tags   []string `mold:"dive,trim|panic"`
+		Panics string   `mold:"panic"`
+	}
+
+	tform := New()
+	tform.Register("trim", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+		return nil
+	})
+	tform.Register("prefix", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(fl.Param() + fl.Field().String())
+		return nil
+	})
+	tform.Register("panic", func(ctx context.Context, fl FieldLevel) error {
+		panic("panic")
+	})
+
+	var wrapped, calls []string
+	tform.Use(func(tag, param string, next Func) Func {
+		wrapped = append(wrapped, tag+"="+param)
+		return func(ctx context.Context, fl FieldLevel) error {
+			calls = append(calls, "outer "+tag+" "+fl.Namespace())
+			return next(ctx, fl)
+		}
+	}, func(tag, param string, next Func) Func {
+		return func(ctx context.Context, fl FieldLevel) (err error) {
+			calls = append(calls, "inner "+tag)
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("recovered %s: %v", tag, r)
+				}
+			}()
+			return next(ctx, fl)
+		}
+	})
+
+	tt := Test{Name: " b ", Tags: []string{" c "}}
+	err := tform.Struct(context.Background(), &tt)
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "recovered panic: panic")
+	Equal(t, tt.Name, "ab")
+	Equal(t, tt.Tags[0], "c")
+	Equal(t, wrapped, []string{"trim=", "prefix=a", "trim=", "panic=", "panic="})
+	Equal(t, calls, []string{
+		"outer trim Test.Name", "inner trim",
+		"outer prefix Test.Name", "inner prefix",
+		"outer trim Test.Tags[0]", "inner trim",
+		"outer panic Test.Panics", "inner panic",
+	})
+
+	// middleware is only applied when the tags are parsed
+	calls = nil
+	err = tform.Struct(context.Background(), &tt)
+	NotEqual(t, err, nil)
+	Equal(t, len(wrapped), 5)
+	Equal(t, len(calls), 8)
+}
+
+// generatedNode transforms itself and the nodes it refers to like generated code,
+// which does not detect cycles.
+type generatedNode struct {
+	Name string `mold:"trim"`
+	Next *generatedNode
+}
+
+func (g *generatedNode) MoldTag(ctx context.Context, tagName string) (bool, error) {
+	if tagName != "mold" {
+		return false, nil
+	}
+
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Next != nil {
+		return g.Next.MoldTag(ctx, tagName)
+	}
+	return true, nil
+}
+
+func TestCycles(t *testing.T) {
+	type Node struct {
+		Name     string `mold:"trim"`
+		Parent   *Node
+		Children []interface{}          `mold:"dive"`
+		Values   map[string]interface{} `mold:"dive"`
+	}
+
+	tform := New()
+	tform.Register("trim", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+		return nil
+	})
+
+	n := &Node{Name: " a "}
+	n.Parent = n
+	err := tform.Struct(context.Background(), n)
+	var cycle *ErrCycle
+	Equal(t, errors.As(err, &cycle), true)
+	Equal(t, cycle.Namespace(), "Node.Parent")
+	Equal(t, err.Error(), "mold: cycle detected at 'Node.Parent' of type *modifier.Node")
+
+	// slices and maps reached from their own elements
+	n = &Node{Children: make([]interface{}, 1)}
+	n.Children[0] = &Node{Children: n.Children}
+	err = tform.Struct(context.Background(), n)
+	Equal(t, errors.As(err, &cycle), true)
+	Equal(t, cycle.Namespace(), "Node.Children[0].Children")
+
+	n = &Node{Values: make(map[string]interface{})}
+	n.Values["self"] = &Node{Values: n.Values}
+	err = tform.Struct(context.Background(), n)
+	Equal(t, errors.As(err, &cycle), true)
+	Equal(t, cycle.Namespace(), "Node.Values[self].Values")
+
+	// shared values are no cycle
+	shared := &Node{Name: " shared "}
+	n = &Node{Parent: shared, Children: []interface{}{shared, shared}}
+	err = tform.Struct(context.Background(), n)
+	Equal(t, err, nil)
+	Equal(t, shared.Name, "shared")
+
+	// depth
+	n = &Node{Name: " 1 ", Parent: &Node{Name: " 2 ", Parent: &Node{Name: " 3 "}}}
+	tform.SetMaxDepth(2)
+	err = tform.Struct(context.Background(), n)
+	var maxDepth *ErrMaxDepth
+	Equal(t, errors.As(err, &maxDepth), true)
+	Equal(t, maxDepth.Namespace(), "Node.Parent.Parent")
+	Equal(t, maxDepth.Depth(), 2)
+	Equal(t, err.Error(), "mold: maximum depth of 2 exceeded at 'Node.Parent.Parent'")
+
+	s := [][][]string{{{" a "}}}
+	err = tform.Field(context.Background(), &s, "dive,dive,dive,trim")
+	Equal(t, errors.As(err, &maxDepth), true)
+	Equal(t, maxDepth.Namespace(), "[0][0]")
+
+	// skipping
+	tform.SetSkipLimited(true)
+	err = tform.Struct(context.Background(), n)
+	Equal(t, err, nil)
+	Equal(t, n.Name, "1")
+	Equal(t, n.Parent.Name, "2")
+	Equal(t, n.Parent.Parent.Name, " 3 ")
+
+	tform.SetMaxDepth(0)
+	n = &Node{Name: " a "}
+	n.Parent = n
+	err = tform.Struct(context.Background(), n)
+	Equal(t, err, nil)
+	Equal(t, n.Name, "a")
+
+	// generated code is not used for types which may refer to themselves
+	tform.SetSkipLimited(false)
+	tform.SetUseGenerated(true)
+	gn := &generatedNode{Name: " a "}
+	gn.Next = gn
+	err = tform.Struct(context.Background(), gn)
+	Equal(t, errors.As(err, &cycle), true)
+	Equal(t, cycle.Namespace(), "generatedNode.Next")
+}
+
+func TestInterceptorCommit(t *testing.T) {
+	type Nullable struct {
+		Value string
+		Set   bool
+	}
+
+	type Test struct {
+		Value  Nullable  `mold:"trim,suffix"`
+		Ptr    *Nullable `mold:"suffix"`
+		Ignore Nullable
+	}
+
+	tform := New()
+	tform.Register("trim", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+		return nil
+	})
+	tform.Register("suffix", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(fl.Field().String() + "!")
+		return nil
+	})
+
+	var commits []string
+	tform.RegisterInterceptorWithCommit(func(current reflect.Value) reflect.Value {
+		return current.Field(0)
+	}, func(current, inner reflect.Value, tag string, changed bool) {
+		commits = append(commits, fmt.Sprintf("%s:%s:%t", tag, inner.String(), changed))
+		current.Field(1).SetBool(true)
+	}, Nullable{})
+
+	tt := Test{Value: Nullable{Value: " a "}, Ptr: &Nullable{Value: "b"}}
+	err := tform.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt.Value, Nullable{Value: "a!", Set: true})
+	Equal(t, *tt.Ptr, Nullable{Value: "b!", Set: true})
+	Equal(t, tt.Ignore, Nullable{})
+	Equal(t, commits, []string{"trim:a:true", "suffix:a!:true", "suffix:b!:true"})
+
+	// unchanged values
+	commits = nil
+	tt = Test{Value: Nullable{Value: "a"}, Ptr: &Nullable{}}
+	err = tform.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, commits, []string{"trim:a:false", "suffix:a!:true", "suffix:!:true"})
+
+	// registering the interceptor again removes the commit
+	tform.RegisterInterceptor(func(current reflect.Value) reflect.Value {
+		return current.Field(0)
+	}, Nullable{})
+
+	commits = nil
+	tt = Test{Value: Nullable{Value: "a"}, Ptr: &Nullable{}}
+	err = tform.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt.Value, Nullable{Value: "a!"})
+	Equal(t, len(commits), 0)
+}
+
+func TestParams(t *testing.T) {
+	tform := New()
+	var params []Params
+	var param []string
+	tform.Register("set", func(ctx context.Context, fl FieldLevel) error {
+		param = append(param, fl.Param())
+		params = append(params, fl.Params())
+		fl.Field().SetString(fl.Param())
+		return nil
+	})
+	tform.Register("fail", func(ctx context.Context, fl FieldLevel) error {
+		return errors.New("fail")
+	})
+
+	tests := []struct {
+		tags   string
+		param  string
+		params Params
+	}{
+		{tags: "set", param: "", params: Params{}},
+		{tags: "set=a", param: "a", params: Params{Args: []string{"a"}}},
+		{tags: "set=a0x2Cb0x7C", param: "a,b|", params: Params{Args: []string{"a,b|"}}},
+		{tags: "set='a, b'", param: "a, b", params: Params{Args: []string{"a, b"}}},
+		{tags: "set='a|b',set='it\\'s \\\\'", param: "it's \\", params: Params{Args: []string{"it's \\"}}},
+		{tags: "set=it's", param: "it's", params: Params{Args: []string{"it's"}}},
+		{tags: "set=1 '2 3'  4", param: "1 2 3  4", params: Params{Args: []string{"1", "2 3", "4"}}},
+		{tags: "set=x width:10 char:' ' 'a:b'", param: "x width:10 char:  a:b", params: Params{
+			Args:  []string{"x", "a:b"},
+			Named: map[string]string{"width": "10", "char": " "},
+		}},
+		{tags: "fail|set='a|b, c'", param: "a|b, c", params: Params{Args: []string{"a|b, c"}}},
+	}
+
+	for _, tc := range tests {
+		param, params = nil, nil
+		var s string
+		err := tform.Field(context.Background(), &s, tc.tags)
+		Equal(t, err, nil)
+		Equal(t, s, tc.param)
+		Equal(t, param[len(param)-1], tc.param)
+		Equal(t, params[len(params)-1], tc.params)
+	}
+
+	p := Params{Args: []string{"a"}, Named: map[string]string{"b": "c"}}
+	Equal(t, p.Arg(0), "a")
+	Equal(t, p.Arg(1), "")
+	v, ok := p.Get("b")
+	Equal(t, ok, true)
+	Equal(t, v, "c")
+	_, ok = p.Get("a")
+	Equal(t, ok, false)
+
+	type Test struct {
+		String string `mold:"set='a, b',set=c"`
+	}
+	var tt Test
+	err := tform.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt.String, "c")
+
+	// quotes which aren't closed are taken literally, as before quotes were supported
+	for tags, expected := range map[string]string{
+		"set='":           "'",
+		"set='a":          "'a",
+		"set='a'b":        "'a'b",
+		"set=1 'a":        "1 'a",
+		"set=a,set='b":    "'b",
+		"fail|set='a":     "'a",
+		"set='a' 'b":      "'a' 'b",
+		"set='a',set='b'": "b",
+	} {
+		var s string
+		err = tform.Field(context.Background(), &s, tags)
+		Equal(t, err, nil)
+		Equal(t, s, expected)
+	}
+}
+
+func TestStructFiltered(t *testing.T) {
+	type Address struct {
+		Street string `mold:"trim"`
+		City   string `mold:"trim"`
+	}
+
+	type User struct {
+		Name      string `mold:"trim"`
+		Email     string `mold:"trim"`
+		Address   *Address
+		Addresses []Address         `mold:"dive"`
+		Tags      map[string]string `mold:"dive,trim"`
+	}
+
+	tform := New()
+	tform.Register("trim", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+		return nil
+	})
+
+	newUser := func() *User {
+		return &User{
+			Name:      " name ",
+			Email:     " email ",
+			Address:   &Address{Street: " street ", City: " city "},
+			Addresses: []Address{{Street: " s0 ", City: " c0 "}, {Street: " s1 ", City: " c1 "}},
+			Tags:      map[string]string{"a": " a ", "b": " b "},
+		}
+	}
+
+	u := newUser()
+	err := tform.StructPartial(context.Background(), u, "Name", "Address.City", "Addresses[1]", "Tags[b]")
+	Equal(t, err, nil)
+	Equal(t, u.Name, "name")
+	Equal(t, u.Email, " email ")
+	Equal(t, *u.Address, Address{Street: " street ", City: "city"})
+	Equal(t, u.Addresses, []Address{{Street: " s0 ", City: " c0 "}, {Street: "s1", City: "c1"}})
+	Equal(t, u.Tags, map[string]string{"a": " a ", "b": "b"})
+
+	u = newUser()
+	err = tform.StructExcept(context.Background(), u, "Name", "Address", "Addresses[0].City", "Tags[a]")
+	Equal(t, err, nil)
+	Equal(t, u.Name, " name ")
+	Equal(t, u.Email, "email")
+	Equal(t, *u.Address, Address{Street: " street ", City: " city "})
+	Equal(t, u.Addresses, []Address{{Street: "s0", City: " c0 "}, {Street: "s1", City: "c1"}})
+	Equal(t, u.Tags, map[string]string{"a": " a ", "b": "b"})
+
+	u = newUser()
+	var namespaces []string
+	err = tform.StructFiltered(context.Background(), u, func(ns []byte) bool {
+		namespaces = append(namespaces, string(ns))
+		return strings.HasSuffix(string(ns), "City")
+	})
+	Equal(t, err, nil)
+	Equal(t, u.Name, "name")
+	Equal(t, *u.Address, Address{Street: "street", City: " city "})
+	Equal(t, u.Addresses, []Address{{Street: "s0", City: " c0 "}, {Street: "s1", City: " c1 "}})
+	Equal(t, namespaces[:5], []string{"User.Name", "User.Email", "User.Address", "User.Address.Street", "User.Address.City"})
+
+	// names are not matched partially
+	u = newUser()
+	err = tform.StructPartial(context.Background(), u, "Nam", "Addresses[1].Cit")
+	Equal(t, err, nil)
+	Equal(t, u.Name, " name ")
+	Equal(t, u.Addresses[1].City, " c1 ")
+
+	err = tform.StructPartial(context.Background(), nil, "Name")
+	Equal(t, err.Error(), "mold: StructPartial(nil)")
+}
+
+func TestGroups(t *testing.T) {
+	type Inner struct {
+		Status string `mold:"trim" mold.create:"default=pending"`
+	}
+
+	type Test struct {
+		Name    string `mold:"trim" mold.create:"default=new" mold.update:"suffix=!"`
+		Secret  string `mold:"trim" mold.public:"-"`
+		Ignored string `mold:"-" mold.create:"default=x"`
+		Only    string `mold.update:"trim"`
+		Inner   Inner
+	}
+
+	tform := New()
+	tform.Register("trim", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+		return nil
+	})
+	tform.Register("default", func(ctx context.Context, fl FieldLevel) error {
+		if fl.Field().String() == "" {
+			fl.Field().SetString(fl.Param())
+		}
+		return nil
+	})
+	tform.Register("suffix", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(fl.Field().String() + fl.Param())
+		return nil
+	})
+
+	newTest := func() Test {
+		return Test{Name: " ", Secret: " s ", Only: " o ", Inner: Inner{Status: " "}}
+	}
+
+	tt := newTest()
+	err := tform.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt, Test{Secret: "s", Only: " o "})
+
+	tt = newTest()
+	err = tform.Struct(WithGroups(context.Background(), "create"), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt, Test{Name: "new", Secret: "s", Only: " o ", Inner: Inner{Status: "pending"}})
+
+	tt = newTest()
+	err = tform.Struct(WithGroups(context.Background(), "create", "update", "public"), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt, Test{Name: "new!", Secret: " s ", Only: "o", Inner: Inner{Status: "pending"}})
+
+	tt = newTest()
+	err = tform.Struct(WithGroups(context.Background(), "update", "create"), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt.Name, "!")
+
+	// each set of groups is cached separately
+	_, ok := tform.cCache.Get(structKey{typ: reflect.TypeOf(Test{})})
+	Equal(t, ok, true)
+	_, ok = tform.cCache.Get(structKey{typ: reflect.TypeOf(Test{}), groups: "create,update,public"})
+	Equal(t, ok, true)
+	_, ok = tform.cCache.Get(structKey{typ: reflect.TypeOf(Inner{}), groups: "update,create"})
+	Equal(t, ok, true)
+
+	type Invalid struct {
+		Name string `mold:"trim" mold.create:"unknown"`
+	}
+	var iv Invalid
+	err = tform.Struct(context.Background(), &iv)
+	Equal(t, err, nil)
+	err = tform.Struct(WithGroups(context.Background(), "create"), &iv)
+	Equal(t, err.Error(), "unregistered/undefined transformation 'unknown' found on field Name")
+}
+
+func TestLanguage(t *testing.T) {
+	ctx := context.Background()
+	_, ok := Language(ctx)
+	Equal(t, ok, false)
+
+	tag, ok := Language(WithLanguage(ctx, language.Turkish))
+	Equal(t, ok, true)
+	Equal(t, tag, language.Turkish)
+}
+
+type moldEmail string
+
+func (e *moldEmail) Mold(ctx context.Context) error {
+	*e = moldEmail(strings.ToLower(strings.TrimSpace(string(*e))))
+	return nil
+}
+
+type moldMoney struct {
+	Cents int64
+}
+
+func (m moldMoney) Mold(ctx context.Context) error {
+	if m.Cents < 0 {
+		return errors.New("negative amount")
+	}
+	return nil
+}
+
+type moldUser struct {
+	Email  moldEmail   `mold:"suffix"`
+	Emails []moldEmail `mold:"dive"`
+	Ptr    *moldEmail
+	Nil    *moldEmail
+	Map    map[string]moldEmail `mold:"dive"`
+	Iface  interface{}
+	Money  moldMoney
+	order  []string
+}
+
+func (u *moldUser) Mold(ctx context.Context) error {
+	u.order = append(u.order, "user "+string(u.Email))
+	return nil
+}
+
+type moldGenerated struct {
+	Email moldEmail
+}
+
+func (g *moldGenerated) MoldTag(ctx context.Context, tagName string) (bool, error) {
+	return true, nil
+}
+
+func TestMoldable(t *testing.T) {
+	tform := New()
+	tform.Register("suffix", func(ctx context.Context, fl FieldLevel) error {
+		fl.Field().SetString(fl.Field().String() + "!")
+		return nil
+	})
+
+	newUser := func() *moldUser {
+		e := moldEmail(" C@D ")
+		return &moldUser{
+			Email:  " A@B ",
+			Emails: []moldEmail{" X ", " Y "},
+			Ptr:    &e,
+			Map:    map[string]moldEmail{"k": " K "},
+			Iface:  moldEmail(" I "),
+		}
+	}
+
+	u := newUser()
+	err := tform.Struct(context.Background(), u)
+	Equal(t, err, nil)
+	Equal(t, u.Email, moldEmail("a@b !"))
+	Equal(t, u.Emails, []moldEmail{"x", "y"})
+	Equal(t, *u.Ptr, moldEmail("c@d"))
+	Equal(t, u.Nil == nil, true)
+	Equal(t, u.Map, map[string]moldEmail{"k": "k"})
+	Equal(t, u.Iface, moldEmail("i"))
+	Equal(t, u.order, []string{"user a@b !"})
+
+	tform.SetMoldFirst(true)
+	u = newUser()
+	err = tform.Struct(context.Background(), u)
+	Equal(t, err, nil)
+	Equal(t, u.Email, moldEmail("a@b!"))
+	Equal(t, u.order, []string{"user  A@B "})
+
+	e := moldEmail(" E ")
+	err = tform.Field(context.Background(), &e, "suffix")
+	Equal(t, err, nil)
+	Equal(t, e, moldEmail("e!"))
+
+	// value receivers
+	u = newUser()
+	u.Money.Cents = -1
+	err = tform.Struct(context.Background(), u)
+	Equal(t, err.Error(), "negative amount")
+
+	tform.SetCollectErrors(true)
+	err = tform.Struct(context.Background(), u)
+	Equal(t, err.Error(), "transformation of 'moldUser.Money' failed: negative amount")
+
+	// generated code does not call Mold
+	g := moldGenerated{Email: " G "}
+	err = New().Struct(context.Background(), &g)
+	Equal(t, err, nil)
+	Equal(t, g.Email, moldEmail("g"))
+}
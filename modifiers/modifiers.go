@@ -1,32 +1,73 @@
 package modifiers
 
-import "github.com/pchchv/modifier"
+import (
+	"context"
+	"reflect"
+
+	"github.com/pchchv/modifier"
+)
+
+// StringFunc transforms a string using the param of the tag.
+type StringFunc func(ctx context.Context, s, param string) (string, error)
+
+// stringFuncs are the transformations of strings registered by New.
+var stringFuncs = map[string]StringFunc{
+	"camel":               camelCase,
+	"lcase":               toLower,
+	"ltrim":               trimLeft,
+	"name":                nameCase,
+	"rtrim":               trimRight,
+	"snake":               snakeCase,
+	"slug":                slugCase,
+	"strip_alpha_unicode": stripAlphaUnicodeCase,
+	"strip_alpha":         stripAlphaCase,
+	"strip_num_unicode":   stripNumUnicodeCase,
+	"strip_num":           stripNumCase,
+	"strip_punctuation":   stripPunctuation,
+	"title":               titleCase,
+	"tprefix":             trimPrefix,
+	"trim":                trimSpace,
+	"tsuffix":             trimSuffix,
+	"ucase":               toUpper,
+	"ucfirst":             uppercaseFirstCharacterCase,
+}
 
 // New returns a modifier with defaults registered.
 func New() *modifier.Transformer {
 	mod := modifier.New()
-	mod.Register("camel", camelCase)
 	mod.Register("default", defaultValue)
 	mod.Register("empty", empty)
-	mod.Register("lcase", toLower)
-	mod.Register("ltrim", trimLeft)
-	mod.Register("name", nameCase)
-	mod.Register("rtrim", trimRight)
 	mod.Register("set", setValue)
-	mod.Register("snake", snakeCase)
-	mod.Register("slug", slugCase)
-	mod.Register("strip_alpha_unicode", stripAlphaUnicodeCase)
-	mod.Register("strip_alpha", stripAlphaCase)
-	mod.Register("strip_num_unicode", stripNumUnicodeCase)
-	mod.Register("strip_num", stripNumCase)
-	mod.Register("strip_punctuation", stripPunctuation)
 	mod.Register("substr", subStr)
-	mod.Register("title", titleCase)
-	mod.Register("tprefix", trimPrefix)
-	mod.Register("trim", trimSpace)
-	mod.Register("tsuffix", trimSuffix)
-	mod.Register("ucase", toUpper)
-	mod.Register("ucfirst", uppercaseFirstCharacterCase)
+	for tag, fn := range stringFuncs {
+		mod.Register(tag, stringFn(fn))
+	}
 	mod.SetTagName("mod")
 	return mod
 }
+
+// StringFuncs returns the transformations of strings registered by New by their tag,
+// except substr which reads the arguments of it's param,
+// e. g. for code generated by cmd/moldgen which calls them directly.
+func StringFuncs() map[string]StringFunc {
+	m := make(map[string]StringFunc, len(stringFuncs))
+	for tag, fn := range stringFuncs {
+		m[tag] = fn
+	}
+	return m
+}
+
+// stringFn returns a Func which applies fn to string values.
+func stringFn(fn StringFunc) modifier.Func {
+	return func(ctx context.Context, fl modifier.FieldLevel) error {
+		switch fl.Field().Kind() {
+		case reflect.String:
+			s, err := fn(ctx, fl.Field().String(), fl.Param())
+			if err != nil {
+				return err
+			}
+			fl.Field().SetString(s)
+		}
+		return nil
+	}
+}
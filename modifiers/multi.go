@@ -2,9 +2,14 @@ package modifiers
 
 import (
 	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pchchv/modifier"
@@ -13,10 +18,100 @@ import (
 var (
 	timeType     = reflect.TypeOf(time.Time{})
 	durationType = reflect.TypeOf(time.Duration(0))
+	parsersLock  sync.RWMutex
+	parsers      = map[reflect.Type]ParseFunc{
+		reflect.TypeOf(url.URL{}): parseURL,
+	}
 )
 
+// ParseFunc parses the param of the set and default modifiers into a value of the type it has been registered for,
+// or a value of the same kind which can be converted to it.
+type ParseFunc func(param string) (interface{}, error)
+
+// RegisterParser registers fn to parse the params of the set and default modifiers
+// for values of the types of the given values, e. g. RegisterParser(parseMoney, Money{}),
+// replacing the parsing of built-in kinds and unmarshalers.
+// Parsers are shared by all Transformers.
+//
+// NOTE: this function is not thread-safe with regard to running transformations, register parsers beforehand.
+// Code generated by cmd/moldgen sets values of predeclared types and time.Duration itself,
+// it does not use parsers registered for them.
+func RegisterParser(fn ParseFunc, types ...interface{}) {
+	parsersLock.Lock()
+	defer parsersLock.Unlock()
+	for _, typ := range types {
+		parsers[reflect.TypeOf(typ)] = fn
+	}
+}
+
+// parser returns the parser registered for typ.
+func parser(typ reflect.Type) (ParseFunc, bool) {
+	parsersLock.RLock()
+	defer parsersLock.RUnlock()
+	fn, ok := parsers[typ]
+	return fn, ok
+}
+
+// parseURL parses a url.URL.
+func parseURL(param string) (interface{}, error) {
+	u, err := url.Parse(param)
+	if err != nil {
+		return nil, err
+	}
+	return *u, nil
+}
+
+// setParsed sets field to param using the parser registered for its type,
+// or encoding.TextUnmarshaler or json.Unmarshaler if implemented by its pointer,
+// and returns false if neither applies.
+// For json.Unmarshaler params which are no valid JSON are passed as JSON string.
+func setParsed(field reflect.Value, param string) (bool, error) {
+	typ := field.Type()
+	if fn, ok := parser(typ); ok {
+		v, err := fn(param)
+		if err != nil {
+			return true, err
+		}
+
+		value := reflect.ValueOf(v)
+		switch {
+		case !value.IsValid():
+			field.Set(reflect.Zero(typ))
+		case value.Type().AssignableTo(typ):
+			field.Set(value)
+		case value.Kind() == typ.Kind() && value.Type().ConvertibleTo(typ):
+			// e. g. a string for a named string type
+			field.Set(value.Convert(typ))
+		default:
+			return true, fmt.Errorf("modifiers: parser for %s returned %s", typ, value.Type())
+		}
+		return true, nil
+	}
+
+	// time.Time keeps supporting now and utc
+	if typ == timeType || !field.CanAddr() || !field.Addr().CanInterface() {
+		return false, nil
+	}
+
+	switch u := field.Addr().Interface().(type) {
+	case encoding.TextUnmarshaler:
+		return true, u.UnmarshalText([]byte(param))
+	case json.Unmarshaler:
+		data := []byte(param)
+		if !json.Valid(data) {
+			data = []byte(strconv.Quote(param))
+		}
+		return true, u.UnmarshalJSON(data)
+	}
+	return false, nil
+}
+
 // setValue allows setting of a specified value.
 func setValueInner(field reflect.Value, param string) error {
+	if ok, err := setParsed(field, param); ok {
+		return err
+	}
+
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(param)
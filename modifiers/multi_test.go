@@ -2,6 +2,13 @@ package modifiers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -352,6 +359,112 @@ func TestDefaultSetSpecialTypes(t *testing.T) {
 	}
 }
 
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 1
+	case "info":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type point struct {
+	X, Y int
+}
+
+func (p *point) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s != "origin" {
+			return errors.New("unknown point")
+		}
+		*p = point{}
+		return nil
+	}
+
+	type raw point
+	return json.Unmarshal(data, (*raw)(p))
+}
+
+type money struct {
+	cents int64
+}
+
+type code string
+
+func TestSetParsed(t *testing.T) {
+	RegisterParser(func(param string) (interface{}, error) {
+		f, ok := new(big.Float).SetString(param)
+		if !ok {
+			return nil, errors.New("invalid amount")
+		}
+		cents, _ := f.Mul(f, big.NewFloat(100)).Int64()
+		return money{cents: cents}, nil
+	}, money{})
+	RegisterParser(func(param string) (interface{}, error) {
+		return strings.ToUpper(param), nil
+	}, code(""))
+
+	type Test struct {
+		IP      net.IP     `mod:"default=127.0.0.1"`
+		Addr    netip.Addr `mod:"default=::1"`
+		Int     big.Int    `mod:"set=12345678901234567890"`
+		IntPtr  *big.Int   `mod:"default=42"`
+		URL     url.URL    `mod:"default=https://example.com/path"`
+		Level   level      `mod:"default=info"`
+		Point   point      `mod:"set='{\"X\":1,\"Y\":2}'"`
+		Origin  point      `mod:"set=origin"`
+		Money   money      `mod:"default=1.25"`
+		Code    code       `mod:"set=abc"`
+		Existed level      `mod:"default=info"`
+	}
+
+	conform := New()
+	tt := Test{Existed: 1}
+	err := conform.Struct(context.Background(), &tt)
+	Equal(t, err, nil)
+	Equal(t, tt.IP.String(), "127.0.0.1")
+	Equal(t, tt.Addr, netip.MustParseAddr("::1"))
+	Equal(t, tt.Int.String(), "12345678901234567890")
+	Equal(t, tt.IntPtr.String(), "42")
+	Equal(t, tt.URL.String(), "https://example.com/path")
+	Equal(t, tt.Level, level(2))
+	Equal(t, tt.Point, point{X: 1, Y: 2})
+	Equal(t, tt.Origin, point{})
+	Equal(t, tt.Money, money{cents: 125})
+	Equal(t, tt.Code, code("ABC"))
+	Equal(t, tt.Existed, level(1))
+
+	var l level
+	err = conform.Field(context.Background(), &l, "set=trace")
+	Equal(t, err.Error(), "unknown level")
+
+	var p point
+	err = conform.Field(context.Background(), &p, "set=nowhere")
+	Equal(t, err.Error(), "unknown point")
+
+	var m money
+	err = conform.Field(context.Background(), &m, "set=abc")
+	Equal(t, err.Error(), "invalid amount")
+
+	var u url.URL
+	err = conform.Field(context.Background(), &u, "set=%zz")
+	NotEqual(t, err, nil)
+
+	type wrong string
+	RegisterParser(func(param string) (interface{}, error) {
+		return 1, nil
+	}, wrong(""))
+	var w wrong
+	err = conform.Field(context.Background(), &w, "set=a")
+	Equal(t, err.Error(), "modifiers: parser for modifiers.wrong returned int")
+}
+
 func TestEmpty(t *testing.T) {
 	type State int
 
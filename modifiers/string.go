@@ -3,6 +3,7 @@ package modifiers
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -35,193 +36,160 @@ var (
 )
 
 // trimLeft trims extra left hand side of string using provided cutset.
-func trimLeft(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(strings.TrimLeft(fl.Field().String(), fl.Param()))
-	}
-	return nil
+func trimLeft(_ context.Context, s, param string) (string, error) {
+	return strings.TrimLeft(s, param), nil
 }
 
 // trimRight trims extra right hand side of string using provided cutset.
-func trimRight(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(strings.TrimRight(fl.Field().String(), fl.Param()))
-	}
-	return nil
+func trimRight(_ context.Context, s, param string) (string, error) {
+	return strings.TrimRight(s, param), nil
 }
 
 // trimPrefix trims the string of a prefix.
-func trimPrefix(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(strings.TrimPrefix(fl.Field().String(), fl.Param()))
-	}
-	return nil
+func trimPrefix(_ context.Context, s, param string) (string, error) {
+	return strings.TrimPrefix(s, param), nil
 }
 
 // trimSuffix trims the string of a suffix.
-func trimSuffix(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(strings.TrimSuffix(fl.Field().String(), fl.Param()))
-	}
-	return nil
+func trimSuffix(_ context.Context, s, param string) (string, error) {
+	return strings.TrimSuffix(s, param), nil
 }
 
 // trimSpace trims extra space from text.
-func trimSpace(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(strings.TrimSpace(fl.Field().String()))
+func trimSpace(_ context.Context, s, _ string) (string, error) {
+	return strings.TrimSpace(s), nil
+}
+
+// caseLanguage returns the language used by the case transformations,
+// which is the language tag given as param e. g. lcase=tr,
+// the language of the context set using modifier.WithLanguage or language.Und.
+func caseLanguage(ctx context.Context, param string) (language.Tag, error) {
+	if len(param) > 0 {
+		return language.Parse(param)
 	}
-	return nil
+
+	if tag, ok := modifier.Language(ctx); ok {
+		return tag, nil
+	}
+	return language.Und, nil
 }
 
 // toLower convert string to lower case.
-func toLower(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(strings.ToLower(fl.Field().String()))
+func toLower(ctx context.Context, s, param string) (string, error) {
+	tag, err := caseLanguage(ctx, param)
+	if err != nil {
+		return s, err
 	}
-	return nil
+	return cases.Lower(tag).String(s), nil
 }
 
 // toUpper convert string to upper case.
-func toUpper(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(strings.ToUpper(fl.Field().String()))
+func toUpper(ctx context.Context, s, param string) (string, error) {
+	tag, err := caseLanguage(ctx, param)
+	if err != nil {
+		return s, err
 	}
-	return nil
+	return cases.Upper(tag).String(s), nil
 }
 
 // uppercaseFirstCharacterCase converts a string so that it has only the first capital letter.
 // E. g.: "all lower" -> "All lower".
-func uppercaseFirstCharacterCase(_ context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		s := fl.Field().String()
-		if s == "" {
-			return nil
-		}
+func uppercaseFirstCharacterCase(ctx context.Context, s, param string) (string, error) {
+	if s == "" {
+		return s, nil
+	}
 
-		toRune, size := utf8.DecodeRuneInString(s)
-		if !unicode.IsLower(toRune) {
-			return nil
-		}
+	toRune, _ := utf8.DecodeRuneInString(s)
+	if !unicode.IsLower(toRune) {
+		return s, nil
+	}
 
-		buf := &bytes.Buffer{}
-		buf.WriteRune(unicode.ToUpper(toRune))
-		buf.WriteString(s[size:])
-		fl.Field().SetString(buf.String())
+	tag, err := caseLanguage(ctx, param)
+	if err != nil {
+		return s, err
 	}
 
-	return nil
+	// the first word is title cased as a whole, e.g. for the Dutch "ij"
+	end := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsMark(r) })
+	if end < 0 {
+		end = len(s)
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(cases.Title(tag, cases.NoLower).String(s[:end]))
+	buf.WriteString(s[end:])
+	return buf.String(), nil
 }
 
 // snakeCase converts string to snake case.
-func snakeCase(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(snakecase.Snakecase(fl.Field().String()))
-	}
-	return nil
+func snakeCase(_ context.Context, s, _ string) (string, error) {
+	return snakecase.Snakecase(s), nil
 }
 
 // slug converts string to a slug.
-func slugCase(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(slug.Make(fl.Field().String()))
-	}
-	return nil
+func slugCase(_ context.Context, s, _ string) (string, error) {
+	return slug.Make(s), nil
 }
 
 // titleCase converts string to title case,
 // e.g. "this is a sentence" -> "This Is A Sentence".
-func titleCase(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(cases.Title(language.Und, cases.NoLower).String(fl.Field().String()))
+func titleCase(ctx context.Context, s, param string) (string, error) {
+	tag, err := caseLanguage(ctx, param)
+	if err != nil {
+		return s, err
 	}
-	return nil
+	return cases.Title(tag, cases.NoLower).String(s), nil
 }
 
 // stripAlphaCase removes all non-numeric characters.
 // E. g.: "the price is €30,38" -> "3038".
 // NOTE: The struct field will remain a string.
 // No type conversion takes place.
-func stripAlphaCase(_ context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(stripNumRegex.ReplaceAllLiteralString(fl.Field().String(), ""))
-	}
-	return nil
+func stripAlphaCase(_ context.Context, s, _ string) (string, error) {
+	return stripNumRegex.ReplaceAllLiteralString(s, ""), nil
 }
 
 // stripNumCase removes all numbers.
 // Example "39472349D34a34v69e8932747" -> "Dave".
 // NOTE: The struct field will remain a string.
 // No type conversion takes place.
-func stripNumCase(_ context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(stripAlphaRegex.ReplaceAllLiteralString(fl.Field().String(), ""))
-	}
-	return nil
+func stripNumCase(_ context.Context, s, _ string) (string, error) {
+	return stripAlphaRegex.ReplaceAllLiteralString(s, ""), nil
 }
 
 // stripNumUnicodeCase removes non-alpha unicode characters.
 // E. g.: "!@£$%^&'()Hello 1234567890 World+[];\" -> "HelloWorld"
-func stripNumUnicodeCase(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(stripNumUnicodeRegex.ReplaceAllLiteralString(fl.Field().String(), ""))
-	}
-	return nil
+func stripNumUnicodeCase(_ context.Context, s, _ string) (string, error) {
+	return stripNumUnicodeRegex.ReplaceAllLiteralString(s, ""), nil
 }
 
 // stripAlphaUnicodeCase removes alpha unicode characters.
 // E. g.: "Everything's here but the letters!" -> "' !".
-func stripAlphaUnicodeCase(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(stripAlphaUnicode.ReplaceAllLiteralString(fl.Field().String(), ""))
-	}
-	return nil
+func stripAlphaUnicodeCase(_ context.Context, s, _ string) (string, error) {
+	return stripAlphaUnicode.ReplaceAllLiteralString(s, ""), nil
 }
 
 // stripPunctuation removes punctuation.
 // E. g.: "# M5W-1E6!!!" -> " M5W1E6".
-func stripPunctuation(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(stripPunctuationRegex.ReplaceAllLiteralString(fl.Field().String(), ""))
-	}
-	return nil
+func stripPunctuation(_ context.Context, s, _ string) (string, error) {
+	return stripPunctuationRegex.ReplaceAllLiteralString(s, ""), nil
 }
 
 // camelCase converts string to camel case.
-func camelCase(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(camelcase.Camelcase(fl.Field().String()))
-	}
-	return nil
+func camelCase(_ context.Context, s, _ string) (string, error) {
+	return camelcase.Camelcase(s), nil
 }
 
 // nameCase Trims, strips numbers and special characters (except dashes and spaces separating names),
 // converts multiple spaces and dashes to single characters, title cases multiple names.
 // Example: "3493€848Jo-$%£@Ann " -> "Jo-Ann", " ~~ The Dude ~~" -> "The Dude", "**susan**" -> "Susan",
 // " hugh fearnley-whittingstall" -> "Hugh Fearnley-Whittingstall".
-func nameCase(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		fl.Field().SetString(cases.Title(language.Und, cases.NoLower).String(nameRegex.FindString(onlyOne(strings.ToLower(fl.Field().String())))))
+func nameCase(ctx context.Context, s, param string) (string, error) {
+	tag, err := caseLanguage(ctx, param)
+	if err != nil {
+		return s, err
 	}
-	return nil
+	return cases.Title(tag, cases.NoLower).String(nameRegex.FindString(onlyOne(cases.Lower(tag).String(s)))), nil
 }
 
 func onlyOne(s string) string {
@@ -233,23 +201,33 @@ func onlyOne(s string) string {
 	return s
 }
 
-func subStr(ctx context.Context, fl modifier.FieldLevel) error {
+// subStr keeps the part of a string from the start up to the end given as arguments e. g. `substr=1 3`,
+// which may also be separated by a dash e. g. `substr=1-3`. Without an end the rest of the string is kept.
+func subStr(_ context.Context, fl modifier.FieldLevel) error {
 	switch fl.Field().Kind() {
 	case reflect.String:
 		val := fl.Field().String()
-		params := strings.SplitN(fl.Param(), "-", 2)
-		if len(params) == 0 || len(params[0]) == 0 {
+		args := fl.Params().Args
+		if len(args) == 1 {
+			args = strings.SplitN(args[0], "-", 2)
+		}
+
+		if len(args) == 0 || len(args[0]) == 0 {
 			return nil
 		}
 
-		start, err := strconv.Atoi(params[0])
+		if len(args) > 2 {
+			return fmt.Errorf("modifiers: substr takes a start and an end instead of %q", fl.Param())
+		}
+
+		start, err := strconv.Atoi(args[0])
 		if err != nil {
 			return err
 		}
 
 		end := len(val)
-		if len(params) >= 2 {
-			if end, err = strconv.Atoi(params[1]); err != nil {
+		if len(args) == 2 {
+			if end, err = strconv.Atoi(args[1]); err != nil {
 				return err
 			}
 		}
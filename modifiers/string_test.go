@@ -8,7 +8,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pchchv/modifier"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
 )
 
 var excludedStructs = map[reflect.Type]bool{
@@ -200,6 +202,15 @@ func TestTrimPrefix(t *testing.T) {
 	if iface != expected {
 		t.Fatalf("Unexpected value '%v'\n", iface)
 	}
+
+	// a quote which isn't closed is taken literally
+	s = "'xtest"
+	if err := conform.Field(context.Background(), &s, "tprefix='x"); err != nil {
+		log.Fatal(err)
+	}
+	if s != expected {
+		t.Fatalf("Unexpected value '%s'\n", s)
+	}
 }
 
 func TestTrimSuffix(t *testing.T) {
@@ -325,6 +336,47 @@ func TestToUpper(t *testing.T) {
 	}
 }
 
+func TestCaseLanguage(t *testing.T) {
+	conform := New()
+	turkish := modifier.WithLanguage(context.Background(), language.Turkish)
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		field    string
+		tags     string
+		expected string
+	}{
+		{name: "lcase", ctx: context.Background(), field: "ΟΔΟΣ İSTANBUL", tags: "lcase", expected: "οδος i̇stanbul"},
+		{name: "lcase greek final sigma", ctx: context.Background(), field: "ΟΔΟΣ", tags: "lcase=el", expected: "οδος"},
+		{name: "lcase turkish param", ctx: context.Background(), field: "ISPARTA", tags: "lcase=tr", expected: "ısparta"},
+		{name: "lcase turkish context", ctx: turkish, field: "İSTANBUL", tags: "lcase", expected: "istanbul"},
+		{name: "ucase", ctx: context.Background(), field: "istanbul", tags: "ucase", expected: "ISTANBUL"},
+		{name: "ucase turkish context", ctx: turkish, field: "istanbul", tags: "ucase", expected: "İSTANBUL"},
+		{name: "ucase param overrides context", ctx: turkish, field: "istanbul", tags: "ucase=en", expected: "ISTANBUL"},
+		{name: "title dutch", ctx: context.Background(), field: "ijsland", tags: "title=nl", expected: "IJsland"},
+		{name: "title turkish", ctx: turkish, field: "izmir", tags: "title", expected: "İzmir"},
+		{name: "name turkish", ctx: turkish, field: "İSMAİL", tags: "name", expected: "İsmail"},
+		{name: "ucfirst turkish", ctx: turkish, field: "izmir", tags: "ucfirst", expected: "İzmir"},
+		{name: "ucfirst dutch", ctx: context.Background(), field: "ijsland is koud", tags: "ucfirst=nl", expected: "IJsland is koud"},
+		{name: "ucfirst first word", ctx: context.Background(), field: "hello-world", tags: "ucfirst", expected: "Hello-world"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+			s := tc.field
+			err := conform.Field(tc.ctx, &s, tc.tags)
+			assert.NoError(err)
+			assert.Equal(tc.expected, s)
+		})
+	}
+
+	s := "a"
+	err := conform.Field(context.Background(), &s, "ucase=12345")
+	require.Error(t, err)
+	require.Equal(t, "a", s)
+}
+
 func TestSnakeCase(t *testing.T) {
 	conform := New()
 	s := "ThisIsSNAKEcase"
@@ -913,6 +965,10 @@ func TestSubStr(t *testing.T) {
 	if err := conform.Field(context.Background(), &s, tag); err == nil {
 		t.Fatalf("Unexpected value '%s' instead of error for tag %s\n", s, tag)
 	}
+	tag = "substr=1 2 3"
+	if err := conform.Field(context.Background(), &s, tag); err == nil {
+		t.Fatalf("Unexpected value '%s' instead of error for tag %s\n", s, tag)
+	}
 
 	tests := []struct {
 		tag      string
@@ -958,6 +1014,18 @@ func TestSubStr(t *testing.T) {
 			tag:      "substr=2",
 			expected: "3",
 		},
+		{
+			tag:      "substr=1 2",
+			expected: "2",
+		},
+		{
+			tag:      "substr=1",
+			expected: "23",
+		},
+		{
+			tag:      "substr='1' '2'",
+			expected: "2",
+		},
 	}
 	for _, test := range tests {
 		st := s
@@ -0,0 +1,236 @@
+// Package moldvet defines an Analyzer that checks the transformation tags of struct fields.
+//
+// The mold tags are checked against the transformations of modifier.New,
+// the mod tags against modifiers.New and the scrub tags against scrubbers.New.
+// Transformations and aliases registered by the program itself have to be declared
+// with the -extra flag, e.g. -extra=e164,mod:phone where phone is only known to mod tags.
+//
+// Unknown and invalid tags, keys and endkeys tags not following
+// a dive into a map and dive tags on fields which are no collection are reported.
+package moldvet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pchchv/modifier"
+	"github.com/pchchv/modifier/modifiers"
+	"github.com/pchchv/modifier/scrubbers"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const (
+	diveTag    = "dive"
+	keysTag    = "keys"
+	endKeysTag = "endkeys"
+)
+
+// Analyzer checks the transformation tags of struct fields.
+var Analyzer = &analysis.Analyzer{
+	Name:     "moldvet",
+	Doc:      "check the mold, mod and scrub tags of struct fields",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var extra string
+
+func init() {
+	Analyzer.Flags.StringVar(&extra, "extra", "", "comma separated list of additionally registered transformations and aliases, optionally prefixed by the tag name they are limited to, e.g. e164,mod:phone")
+}
+
+// transformers returns the transformers by the tag name they check.
+func transformers() (map[string]*modifier.Transformer, error) {
+	tfs := map[string]*modifier.Transformer{
+		"mold":  modifier.New(),
+		"mod":   modifiers.New(),
+		"scrub": scrubbers.New(),
+	}
+	if len(extra) == 0 {
+		return tfs, nil
+	}
+
+	for _, name := range strings.Split(extra, ",") {
+		name = strings.TrimSpace(name)
+		tagName, tag, limited := strings.Cut(name, ":")
+		if !limited {
+			tag = tagName
+		}
+
+		for tn, tf := range tfs {
+			if limited && tn != tagName {
+				continue
+			}
+
+			if err := register(tf, tag); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return tfs, nil
+}
+
+// register registers tag with tf, returning the panic of an invalid tag as error.
+func register(tf *modifier.Transformer, tag string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid -extra tag %q: %v", tag, r)
+		}
+	}()
+
+	tf.Register(tag, func(ctx context.Context, fl modifier.FieldLevel) error { return nil })
+	return nil
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	tfs, err := transformers()
+	if err != nil {
+		return nil, err
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		for _, field := range n.(*ast.StructType).Fields.List {
+			if field.Tag == nil {
+				continue
+			}
+
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				continue
+			}
+
+			typ := pass.TypesInfo.TypeOf(field.Type)
+			name := fieldName(field)
+			for _, tagName := range []string{"mold", "mod", "scrub"} {
+				if tags, ok := reflect.StructTag(tag).Lookup(tagName); ok {
+					checkTags(pass, field, tfs[tagName], tagName, name, tags, typ)
+				}
+			}
+		}
+	})
+	return nil, nil
+}
+
+// checkTags reports the problems of the tags of a field.
+func checkTags(pass *analysis.Pass, field *ast.Field, tf *modifier.Transformer, tagName, name, tags string, typ types.Type) {
+	if len(tags) == 0 || tags == "-" {
+		return
+	}
+
+	structural := false
+	if typ != nil {
+		if msg := checkDive(typ, modifier.SplitTags(tags)); len(msg) > 0 {
+			pass.Reportf(field.Tag.Pos(), "%s tag of field %s: %s", tagName, name, msg)
+			structural = true
+		}
+	}
+
+	err := tf.ValidateTags(name, tags)
+	if err == nil || structural && (errors.Is(err, modifier.ErrInvalidKeysTag) || errors.Is(err, modifier.ErrUndefinedKeysTag)) {
+		return
+	}
+	pass.Reportf(field.Tag.Pos(), "%s tag: %s", tagName, err)
+}
+
+// checkDive checks that the dive, keys and endkeys tags match the type of the field.
+func checkDive(typ types.Type, tags []string) string {
+	for i := 0; i < len(tags); i++ {
+		switch tags[i] {
+		case diveTag:
+			key, elem, ok := collection(typ)
+			if !ok {
+				return fmt.Sprintf("%s on %s which is no slice, array or map", diveTag, typ)
+			}
+
+			if elem == nil {
+				// the dynamic type of interfaces is unknown
+				return ""
+			}
+
+			if i+1 < len(tags) && tags[i+1] == keysTag {
+				if key == nil {
+					return fmt.Sprintf("%s on %s which is no map", keysTag, typ)
+				}
+
+				end := i + 2
+				for end < len(tags) && tags[end] != endKeysTag {
+					end++
+				}
+
+				if end == len(tags) {
+					return fmt.Sprintf("%s without %s", keysTag, endKeysTag)
+				}
+
+				if msg := checkDive(key, tags[i+2:end]); len(msg) > 0 {
+					return msg
+				}
+				i = end
+			}
+			typ = elem
+		case keysTag:
+			return fmt.Sprintf("%s must immediately follow %s", keysTag, diveTag)
+		case endKeysTag:
+			return fmt.Sprintf("%s without %s", endKeysTag, keysTag)
+		}
+	}
+	return ""
+}
+
+// collection returns the key type of a map and the element type of a collection
+// typ is or points to. The element type of interfaces is nil.
+func collection(typ types.Type) (key, elem types.Type, ok bool) {
+	for {
+		p, isPtr := typ.Underlying().(*types.Pointer)
+		if !isPtr {
+			break
+		}
+		typ = p.Elem()
+	}
+
+	switch u := typ.Underlying().(type) {
+	case *types.Slice:
+		return nil, u.Elem(), true
+	case *types.Array:
+		return nil, u.Elem(), true
+	case *types.Map:
+		return u.Key(), u.Elem(), true
+	case *types.Interface:
+		return nil, nil, true
+	}
+	return nil, nil, false
+}
+
+// fieldName returns the name of the first field declared by field.
+func fieldName(field *ast.Field) string {
+	if len(field.Names) > 0 {
+		return field.Names[0].Name
+	}
+
+	// embedded field
+	typ := field.Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.SelectorExpr:
+			return t.Sel.Name
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
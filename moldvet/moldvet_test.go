@@ -0,0 +1,24 @@
+package moldvet
+
+import (
+	"testing"
+
+	. "github.com/pchchv/go-assert"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	Equal(t, Analyzer.Flags.Set("extra", "e164,mod:phone"), nil)
+	defer func() { _ = Analyzer.Flags.Set("extra", "") }()
+
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
+
+func TestInvalidExtra(t *testing.T) {
+	Equal(t, Analyzer.Flags.Set("extra", "dive"), nil)
+	defer func() { _ = Analyzer.Flags.Set("extra", "") }()
+
+	_, err := transformers()
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), `invalid -extra tag "dive": Tag 'dive' either contains restricted characters or is the same as a restricted tag needed for normal operation`)
+}
@@ -0,0 +1,30 @@
+package a
+
+type Address struct {
+	Street string `mod:"trim"`
+}
+
+type User struct {
+	Name     string             `mod:"trmi"` // want `mod tag: unregistered/undefined transformation 'trmi' found on field Name`
+	Email    string             `mod:"trim,lcase" scrub:"emails"`
+	Phone    string             `mod:"trim,phone"`
+	SSN      string             `scrub:"phone"` // want `scrub tag: unregistered/undefined transformation 'phone' found on field SSN`
+	Code     string             `mold:"e164"`
+	Title    string             `mod:"title|ucase"`
+	Or       string             `mod:"trim|dive"`   // want `mod tag: invalid tag 'trim\|dive' found on field Or`
+	Empty    string             `mod:"trim,,lcase"` // want `mod tag: invalid tag '' found on field Empty`
+	Tags     []string           `mod:"dive,trim"`
+	Nested   [][]string         `mod:"dive,dive,trim"`
+	Deep     []string           `mod:"dive,dive,trim"` // want `mod tag of field Deep: dive on string which is no slice, array or map`
+	Labels   *map[string]string `mod:"dive,keys,trim,endkeys,lcase"`
+	Keys     []string           `mod:"dive,keys,trim,endkeys"` // want `mod tag of field Keys: keys on \[\]string which is no map`
+	NoDive   map[string]string  `mod:"keys,trim,endkeys"`      // want `mod tag of field NoDive: keys must immediately follow dive`
+	NoEnd    map[string]string  `mod:"dive,keys,trim"`         // want `mod tag of field NoEnd: keys without endkeys`
+	End      string             `mod:"trim,endkeys"`           // want `mod tag of field End: endkeys without keys`
+	Home     Address            `mod:"dive"`                   // want `mod tag of field Home: dive on a.Address which is no slice, array or map`
+	Any      interface{}        `mod:"dive,trim"`
+	Quoted   []string           `mod:"dive,set='x,dive,y'"`
+	Ignored  string             `mod:"-"`
+	Untagged string
+	*Address `mod:"trmi"` // want `mod tag: unregistered/undefined transformation 'trmi' found on field Address`
+}
@@ -0,0 +1,179 @@
+package modifier
+
+import "strings"
+
+const (
+	quote         = '\''
+	escape        = '\\'
+	argSeparator  = ' '
+	nameSeparator = ':'
+)
+
+// Params are the arguments of a tag's param.
+// Arguments are separated by spaces and either positional, e. g. `substr=1 3`,
+// or named, e. g. `pad=width:10 char:'0'`.
+// Arguments may be quoted with single quotes, so that they can contain
+// commas, pipes and spaces, e. g. `set='a, b'`, within quotes a backslash escapes the next character.
+// Quotes which aren't closed are taken literally, as are the quotes of a param with such a quote.
+type Params struct {
+	// Args are the positional arguments in order.
+	Args []string
+	// Named are the named arguments by their name.
+	Named map[string]string
+}
+
+// Arg returns the i'th positional argument, or an empty string if there are less arguments.
+func (p Params) Arg(i int) string {
+	if i < 0 || i >= len(p.Args) {
+		return ""
+	}
+	return p.Args[i]
+}
+
+// Get returns the value of the named argument and whether it was given.
+func (p Params) Get(name string) (string, bool) {
+	v, ok := p.Named[name]
+	return v, ok
+}
+
+// SplitTags splits the tags of a field at the commas separating them
+// the same way the Transformer does, i. e. commas within quoted params don't separate tags.
+func SplitTags(tags string) []string {
+	return splitTags(tags, tagSeparator[0])
+}
+
+// splitTags splits tags at sep, ignoring separators within quoted arguments.
+func splitTags(tags string, sep byte) []string {
+	var parts []string
+	start, argStart := 0, -1
+	for i := 0; i < len(tags); i++ {
+		switch c := tags[i]; {
+		case c == sep:
+			parts = append(parts, tags[start:i])
+			start, argStart = i+1, -1
+		case argStart == -1:
+			// within the tag name
+			if c == tagKeySeparator[0] {
+				argStart = i + 1
+			}
+		case c == argSeparator:
+			argStart = i + 1
+		case c == quote && opensQuote(tags[argStart:i]):
+			// quotes which aren't closed are taken literally
+			if end := closingQuote(tags, i); end != -1 {
+				i = end
+			}
+		}
+	}
+	return append(parts, tags[start:])
+}
+
+// parseParam parses the arguments of the param raw and returns the param with the quotes resolved
+// along with it's arguments. Params without quoted arguments are returned as before,
+// with the hex representations of commas and pipes replaced, which is also the case
+// if a quoted argument isn't closed or followed by other characters e. g. `tprefix='x`.
+func parseParam(raw string) (string, Params) {
+	if param, params, ok := parseArgs(raw, true); ok {
+		return param, params
+	}
+
+	param, params, _ := parseArgs(raw, false)
+	return param, params
+}
+
+// parseArgs parses the arguments of the param raw, resolving quoted arguments if quotes is true.
+// It returns false if a quoted argument isn't closed or followed by other characters.
+func parseArgs(raw string, quotes bool) (param string, params Params, ok bool) {
+	b := new(strings.Builder)
+	for i := 0; i < len(raw); {
+		if raw[i] == argSeparator {
+			b.WriteByte(argSeparator)
+			i++
+			continue
+		}
+
+		var name string
+		if n := nameLen(raw[i:]); n > 0 {
+			name = raw[i : i+n]
+			b.WriteString(replaceHexChars(raw[i : i+n+1]))
+			i += n + 1
+		}
+
+		var value string
+		if quotes && i < len(raw) && raw[i] == quote {
+			end := closingQuote(raw, i)
+			if end == -1 || end+1 < len(raw) && raw[end+1] != argSeparator {
+				return "", Params{}, false
+			}
+			value, i = unescape(raw[i+1:end]), end+1
+		} else {
+			end := strings.IndexByte(raw[i:], argSeparator)
+			if end == -1 {
+				end = len(raw) - i
+			}
+			value, i = replaceHexChars(raw[i:i+end]), i+end
+		}
+		b.WriteString(value)
+
+		if len(name) > 0 {
+			if params.Named == nil {
+				params.Named = make(map[string]string)
+			}
+			params.Named[name] = value
+		} else {
+			params.Args = append(params.Args, value)
+		}
+	}
+	return b.String(), params, true
+}
+
+// opensQuote reports whether a quote following the start of an argument opens a quoted value,
+// which is the case at the start of an argument or after the name of a named argument.
+func opensQuote(arg string) bool {
+	n := nameLen(arg)
+	return len(arg) == 0 || n > 0 && n == len(arg)-1
+}
+
+// nameLen returns the length of the name of a named argument at the start of arg, or 0 if there is none.
+func nameLen(arg string) int {
+	for i := 0; i < len(arg); i++ {
+		c := arg[i]
+		switch {
+		case c == nameSeparator:
+			return i
+		case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9':
+		default:
+			return 0
+		}
+	}
+	return 0
+}
+
+// closingQuote returns the index of the quote closing the one at start, or -1 if there is none.
+func closingQuote(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case escape:
+			i++
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// unescape removes the backslashes escaping characters within a quoted argument.
+func unescape(s string) string {
+	if strings.IndexByte(s, escape) == -1 {
+		return s
+	}
+
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == escape && i+1 < len(s) {
+			i++
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
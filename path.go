@@ -0,0 +1,199 @@
+package modifier
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+const (
+	segmentNone segmentKind = iota
+	segmentRoot
+	segmentEntry
+	segmentField
+	segmentIndex
+	segmentKey
+)
+
+type segmentKind uint8
+
+// segment is an element of the location of a value, the namespaces are only built from them when needed.
+// Segments are shared between paths and never modified, so they can be used by multiple goroutines.
+type segment struct {
+	parent *segment
+	kind   segmentKind
+	name   string // name of the root struct or key of an entry
+	cf     *cField
+	index  int
+	key    reflect.Value
+}
+
+// fieldPath describes the location of the value being transformed.
+// The last element of the location is kept within the path itself,
+// the ones leading to it are shared.
+type fieldPath struct {
+	parent      *segment
+	kind        segmentKind   // segmentNone, segmentField, segmentIndex or segmentKey
+	elem        int           // index of the element
+	mapKey      reflect.Value // key of the map entry
+	cf          *cField
+	structValue reflect.Value // struct containing the field cf
+	depth       int           // number of structs, slices, arrays and maps entered
+	visits      *visit        // references leading to the value
+}
+
+// visit is a pointer, map or slice which has been followed to reach a value.
+// Visits are shared between paths and never modified, so they can be used by multiple goroutines.
+type visit struct {
+	ptr    uintptr
+	typ    reflect.Type
+	parent *visit
+}
+
+// empty reports whether the path has no namespace.
+func (p fieldPath) empty() bool {
+	return p.kind == segmentNone && p.parent == nil
+}
+
+// root returns the path of the top level struct with the given name.
+func (p fieldPath) root(name string) fieldPath {
+	p.parent = &segment{kind: segmentRoot, name: name}
+	return p
+}
+
+// field returns the path of the struct field cf of the struct sv.
+// p must be the path of the struct.
+func (p fieldPath) field(cf *cField, sv reflect.Value) fieldPath {
+	return fieldPath{
+		parent:      p.parent,
+		kind:        segmentField,
+		cf:          cf,
+		structValue: sv,
+		depth:       p.depth,
+		visits:      p.visits,
+	}
+}
+
+// entry returns the path of the value with the given key of a map passed to Map.
+func (p fieldPath) entry(key string) fieldPath {
+	p = p.push()
+	p.parent = &segment{parent: p.parent, kind: segmentEntry, name: key}
+	p.cf = nil
+	return p
+}
+
+// index returns the path of the i'th element of a slice or array.
+// p must be the path of the slice or array.
+func (p fieldPath) index(i int) fieldPath {
+	p.kind, p.elem = segmentIndex, i
+	return p
+}
+
+// key returns the path of the map entry with the given key.
+// p must be the path of the map.
+func (p fieldPath) key(key reflect.Value) fieldPath {
+	p.kind, p.mapKey = segmentKey, key
+	return p
+}
+
+// push returns the path of the value at p as the parent of the values within it.
+func (p fieldPath) push() fieldPath {
+	if p.kind != segmentNone {
+		p.parent = &segment{parent: p.parent, kind: p.kind, cf: p.cf, index: p.elem, key: p.mapKey}
+		p.kind, p.mapKey = segmentNone, reflect.Value{}
+	}
+	return p
+}
+
+// namespace returns the namespace of p, with the alternative names of the fields if alt is true,
+// along with the start of the name of the last field within it, which includes the indices and keys following it.
+func (p fieldPath) namespace(b []byte, alt bool) ([]byte, int) {
+	var name int
+	if p.parent != nil {
+		b, name = p.parent.appendNs(b, alt)
+	}
+	return segment{kind: p.kind, cf: p.cf, index: p.elem, key: p.mapKey}.append(b, name, alt)
+}
+
+// ns returns the namespace of p with the alternative names of the fields.
+func (p fieldPath) ns() string {
+	ns, _ := p.namespace(nil, true)
+	return string(ns)
+}
+
+// structNs returns the namespace of p with the struct field's actual names.
+func (p fieldPath) structNs() string {
+	ns, _ := p.namespace(nil, false)
+	return string(ns)
+}
+
+// appendNs appends the namespace up to s to b and returns it
+// along with the start of the name of the last field within it.
+func (s *segment) appendNs(b []byte, alt bool) ([]byte, int) {
+	var name int
+	if s.parent != nil {
+		b, name = s.parent.appendNs(b, alt)
+	}
+	return s.append(b, name, alt)
+}
+
+// append appends the segment to the namespace b, in which the name of the last field starts at name.
+func (s segment) append(b []byte, name int, alt bool) ([]byte, int) {
+	switch s.kind {
+	case segmentRoot:
+		b = append(b, s.name...)
+	case segmentEntry, segmentField:
+		if len(b) > 0 {
+			b = append(b, namespaceSeparator)
+		}
+
+		name = len(b)
+		switch {
+		case s.kind == segmentEntry:
+			b = append(b, s.name...)
+		case alt:
+			b = append(b, s.cf.altName...)
+		default:
+			b = append(b, s.cf.name...)
+		}
+	case segmentIndex:
+		b = append(strconv.AppendInt(append(b, '['), int64(s.index), 10), ']')
+	case segmentKey:
+		b = fmt.Appendf(b, "[%v]", s.key.Interface())
+	}
+	return b, name
+}
+
+// descend returns the path of the struct, slice, array or map v refers to,
+// with the references followed to reach it added, and reports whether
+// one of them has already been followed, i.e. v is part of a cycle.
+func (p fieldPath) descend(v reflect.Value) (fieldPath, bool) {
+	p = p.push()
+	p.depth++
+	for {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice:
+			if v.IsNil() || v.Kind() == reflect.Slice && v.Len() == 0 {
+				return p, false
+			}
+
+			ptr := v.Pointer()
+			for vis := p.visits; vis != nil; vis = vis.parent {
+				if vis.ptr == ptr && vis.typ == v.Type() {
+					return p, true
+				}
+			}
+			p.visits = &visit{ptr: ptr, typ: v.Type(), parent: p.visits}
+			if v.Kind() != reflect.Ptr {
+				return p, false
+			}
+		case reflect.Interface:
+			if v.IsNil() {
+				return p, false
+			}
+		default:
+			return p, false
+		}
+		v = v.Elem()
+	}
+}
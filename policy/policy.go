@@ -0,0 +1,175 @@
+// Package policy loads transformation policies from YAML or JSON documents,
+// so that the tags applied to fields can be changed without changing code.
+//
+// A policy defines aliases and maps Go type names to the rules of their fields,
+// which are registered with Transformer.RegisterStructRules:
+//
+//	aliases:
+//	  email: trim,lcase
+//	types:
+//	  User:
+//	    Email: email
+//	    Address.City: +title
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/pchchv/modifier"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy maps type names and field paths to tags.
+type Policy struct {
+	// Aliases are registered with Transformer.RegisterAlias.
+	Aliases map[string]string `json:"aliases" yaml:"aliases"`
+	// Types maps the names of types to the rules of their fields
+	// as passed to Transformer.RegisterStructRules.
+	// Types are named by their name, e.g. User, or qualified by their package, e.g. models.User.
+	Types map[string]map[string]string `json:"types" yaml:"types"`
+}
+
+// Load reads a policy from r. As YAML is a superset of JSON both are supported.
+// Unknown keys are reported as errors.
+func Load(r io.Reader) (*Policy, error) {
+	var p Policy
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	return &p, nil
+}
+
+// LoadFile reads a policy from the YAML or JSON file at path.
+func LoadFile(path string) (*Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Apply registers the aliases and rules of the policy with t.
+// The types named by the policy are looked up among the given types,
+// all invalid aliases, unknown types and fields, invalid tags as well as different rules for the same field,
+// e.g. of a struct type used by multiple types, are returned as a joined error.
+// The aliases and rules are only registered if there are no errors.
+//
+// NOTE: like the Register methods of the Transformer, Apply must be called prior to any transformation.
+func (p *Policy) Apply(t *modifier.Transformer, types ...interface{}) error {
+	var errs []error
+	aliases := make(map[string]string, len(p.Aliases))
+	for _, alias := range sortedKeys(p.Aliases) {
+		if err := t.ValidateAlias(alias, p.Aliases[alias]); err != nil {
+			errs = append(errs, fmt.Errorf("policy: alias '%s': %w", alias, err))
+			continue
+		}
+		aliases[alias] = p.Aliases[alias]
+	}
+
+	// the tags of the aliases may refer to each other
+	for _, alias := range sortedKeys(aliases) {
+		if err := t.ValidateTagsWithAliases(alias, aliases[alias], aliases); err != nil {
+			errs = append(errs, fmt.Errorf("policy: alias '%s': %w", alias, err))
+		}
+	}
+
+	byName := make(map[string][]reflect.Type)
+	for _, v := range types {
+		typ := reflect.TypeOf(v)
+		for typ != nil && typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+
+		if typ == nil || len(typ.Name()) == 0 {
+			continue
+		}
+
+		for _, name := range []string{typ.Name(), typ.String()} {
+			if !contains(byName[name], typ) {
+				byName[name] = append(byName[name], typ)
+			}
+		}
+	}
+
+	// rules of different types may refer to the same nested struct type
+	type target struct {
+		typ   reflect.Type
+		field string
+	}
+	type origin struct {
+		name string
+		tags string
+	}
+	registered := make(map[target]origin)
+	var pending []func()
+	for _, name := range sortedKeys(p.Types) {
+		candidates := byName[name]
+		switch {
+		case len(candidates) == 0:
+			errs = append(errs, fmt.Errorf("policy: unknown type '%s'", name))
+			continue
+		case len(candidates) > 1:
+			errs = append(errs, fmt.Errorf("policy: ambiguous type '%s', qualify it with it's package", name))
+			continue
+		}
+
+		v := reflect.New(candidates[0]).Interface()
+		rules := p.Types[name]
+		for _, field := range sortedKeys(rules) {
+			rule := map[string]string{field: rules[field]}
+			if err := t.ValidateStructRule(v, field, rules[field], aliases); err != nil {
+				errs = append(errs, fmt.Errorf("policy: type '%s': %w", name, err))
+				continue
+			}
+
+			st, fld, _ := modifier.StructRuleField(v, field)
+			key := target{typ: st, field: fld}
+			if other, ok := registered[key]; ok && other.tags != rules[field] {
+				errs = append(errs, fmt.Errorf("policy: type '%s': rule '%s' conflicts with the rule '%s' of type '%s' for field '%s' of %s", name, field, other.tags, other.name, fld, st))
+				continue
+			}
+			registered[key] = origin{name: name, tags: rules[field]}
+			pending = append(pending, func() { t.RegisterStructRules(rule, v) })
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for alias, tags := range aliases {
+		t.RegisterAlias(alias, tags)
+	}
+
+	for _, fn := range pending {
+		fn()
+	}
+	return nil
+}
+
+func contains(types []reflect.Type, typ reflect.Type) bool {
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,188 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/pchchv/go-assert"
+	"github.com/pchchv/modifier/modifiers"
+	"github.com/pchchv/modifier/scrubbers"
+)
+
+type Address struct {
+	City string `mod:"trim"`
+}
+
+type User struct {
+	Name    string
+	Email   string `mod:"trim"`
+	SSN     string
+	Address Address
+}
+
+type Company struct {
+	Address *Address
+}
+
+const yamlPolicy = `
+aliases:
+  email: trim,lcase
+types:
+  User:
+    Name: trim,title
+    Email: +lcase
+  policy.Address:
+    City: +ucase
+`
+
+func TestApply(t *testing.T) {
+	p, err := Load(strings.NewReader(yamlPolicy))
+	Equal(t, err, nil)
+	Equal(t, p.Aliases, map[string]string{"email": "trim,lcase"})
+
+	mod := modifiers.New()
+	Equal(t, p.Apply(mod, User{}, &Address{}), nil)
+
+	u := User{Name: " joe smith ", Email: " Joe@Example.com ", Address: Address{City: " berlin "}}
+	Equal(t, mod.Struct(context.Background(), &u), nil)
+	Equal(t, u.Name, "Joe Smith")
+	Equal(t, u.Email, "joe@example.com")
+	Equal(t, u.Address.City, "BERLIN")
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	Equal(t, os.WriteFile(path, []byte(`{"types": {"User": {"SSN": "text"}}}`), 0o600), nil)
+
+	p, err := LoadFile(path)
+	Equal(t, err, nil)
+
+	scrub := scrubbers.New()
+	Equal(t, p.Apply(scrub, User{}), nil)
+
+	u := User{SSN: "123-45-6789"}
+	Equal(t, scrub.Struct(context.Background(), &u), nil)
+	Equal(t, strings.HasPrefix(u.SSN, "<<scrubbed::text::sha1::"), true)
+
+	_, err = LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	NotEqual(t, err, nil)
+
+	p, err = Load(strings.NewReader(""))
+	Equal(t, err, nil)
+	Equal(t, len(p.Types), 0)
+
+	_, err = Load(strings.NewReader("rules: {}"))
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "policy: yaml: unmarshal errors:\n  line 1: field rules not found in type policy.Policy")
+}
+
+func TestApplyErrors(t *testing.T) {
+	p, err := Load(strings.NewReader(`
+aliases:
+  dive: trim
+  bad: trmi
+types:
+  Missing:
+    Name: trim
+  User:
+    Missing: trim
+    Name: bad
+    Email: +lcase,,trim
+    Address.Street: trim
+    SSN: lcase
+`))
+	Equal(t, err, nil)
+
+	mod := modifiers.New()
+	err = p.Apply(mod, User{})
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), strings.Join([]string{
+		"policy: alias 'dive': Alias 'dive' either contains restricted characters or is the same as a restricted tag needed for normal operation",
+		"policy: alias 'bad': unregistered/undefined transformation 'trmi' found on field bad",
+		"policy: unknown type 'Missing'",
+		"policy: type 'User': field 'Street' of struct rule 'Address.Street' not found in policy.User",
+		"policy: type 'User': invalid tag '' found on field Email",
+		"policy: type 'User': field 'Missing' of struct rule 'Missing' not found in policy.User",
+		"policy: type 'User': unregistered/undefined transformation 'trmi' found on field Name",
+	}, "\n"))
+
+	// no rules and aliases are registered on error
+	u := User{SSN: "ABC"}
+	Equal(t, mod.Struct(context.Background(), &u), nil)
+	Equal(t, u.SSN, "ABC")
+	NotEqual(t, mod.ValidateTags("Name", "bad"), nil)
+}
+
+func TestApplyAliases(t *testing.T) {
+	p, err := Load(strings.NewReader(`
+aliases:
+  clean: tidy,lcase
+  tidy: trim
+types:
+  User:
+    Email: clean
+`))
+	Equal(t, err, nil)
+
+	mod := modifiers.New()
+	Equal(t, p.Apply(mod, User{}), nil)
+
+	u := User{Email: " Joe@Example.com "}
+	Equal(t, mod.Struct(context.Background(), &u), nil)
+	Equal(t, u.Email, "joe@example.com")
+
+	p, err = Load(strings.NewReader(`
+aliases:
+  a: b
+  b: a
+  c: c,trim
+types:
+  User:
+    Email: a
+`))
+	Equal(t, err, nil)
+
+	mod = modifiers.New()
+	err = p.Apply(mod, User{})
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), strings.Join([]string{
+		"policy: alias 'a': alias 'b' refers to itself through 'b -> a -> b' on field a",
+		"policy: alias 'b': alias 'a' refers to itself through 'a -> b -> a' on field b",
+		"policy: alias 'c': alias 'c' refers to itself through 'c -> c' on field c",
+		"policy: type 'User': alias 'a' refers to itself through 'a -> b -> a' on field Email",
+	}, "\n"))
+}
+
+func TestApplyConflicts(t *testing.T) {
+	p, err := Load(strings.NewReader(`
+types:
+  User:
+    Address.City: ucase
+  Company:
+    Address.City: lcase
+  Address:
+    City: ucase
+`))
+	Equal(t, err, nil)
+
+	mod := modifiers.New()
+	err = p.Apply(mod, User{}, Company{}, Address{})
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "policy: type 'Company': rule 'Address.City' conflicts with the rule 'ucase' of type 'Address' for field 'City' of policy.Address")
+
+	// rules registered before conflict as well
+	mod = modifiers.New()
+	mod.RegisterStructRules(map[string]string{"City": "lcase"}, Address{})
+	p, err = Load(strings.NewReader(`
+types:
+  User:
+    Address.City: ucase
+`))
+	Equal(t, err, nil)
+	err = p.Apply(mod, User{})
+	NotEqual(t, err, nil)
+	Equal(t, err.Error(), "policy: type 'User': struct rule 'Address.City' of policy.User conflicts with the rule 'lcase' registered for field 'City' of policy.Address")
+}
@@ -5,15 +5,28 @@ const (
 	keysTag            = "keys"
 	ignoreTag          = "-"
 	endKeysTag         = "endkeys"
+	omitEmptyTag       = "omitempty"
+	omitNilTag         = "omitnil"
+	ifTag              = "if"
+	unlessTag          = "unless"
+	guardSeparator     = ':'
 	utf8HexComma       = "0x2C"
+	utf8HexPipe        = "0x7C"
 	tagSeparator       = ","
+	orSeparator        = "|"
 	tagKeySeparator    = "="
 	restrictedTagChars = ".[],|=+()`~!@#$%^&*\\\"/?<>{}"
+	namespaceSeparator = '.'
+	ruleMergePrefix    = "+"
 )
 
 var (
 	restrictedTags = map[string]struct{}{
-		diveTag:   {},
-		ignoreTag: {},
+		diveTag:      {},
+		ignoreTag:    {},
+		omitEmptyTag: {},
+		omitNilTag:   {},
+		ifTag:        {},
+		unlessTag:    {},
 	}
 )
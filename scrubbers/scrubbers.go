@@ -1,16 +1,56 @@
 package scrubbers
 
-import "github.com/pchchv/modifier"
+import (
+	"context"
+	"reflect"
+
+	"github.com/pchchv/modifier"
+)
+
+// StringFunc scrubs a string using the param of the tag.
+type StringFunc func(ctx context.Context, s, param string) (string, error)
+
+// stringFuncs are the scrubbers of strings registered by New.
+var stringFuncs = map[string]StringFunc{
+	"emails": emails,
+	"text":   textFn("text"),
+	"email":  textFn("email"),
+	"name":   textFn("name"),
+	"fname":  textFn("fname"),
+	"lname":  textFn("lname"),
+}
 
 // New returns a scrubber with defaults registered.
 func New() *modifier.Transformer {
 	scrub := modifier.New()
 	scrub.SetTagName("scrub")
-	scrub.Register("emails", emails)
-	scrub.Register("text", textFn("text"))
-	scrub.Register("email", textFn("email"))
-	scrub.Register("name", textFn("name"))
-	scrub.Register("fname", textFn("fname"))
-	scrub.Register("lname", textFn("lname"))
+	for tag, fn := range stringFuncs {
+		scrub.Register(tag, stringFn(fn))
+	}
 	return scrub
 }
+
+// StringFuncs returns the scrubbers of strings registered by New by their tag,
+// e. g. for code generated by cmd/moldgen which calls them directly.
+func StringFuncs() map[string]StringFunc {
+	m := make(map[string]StringFunc, len(stringFuncs))
+	for tag, fn := range stringFuncs {
+		m[tag] = fn
+	}
+	return m
+}
+
+// stringFn returns a Func which applies fn to string values.
+func stringFn(fn StringFunc) modifier.Func {
+	return func(ctx context.Context, fl modifier.FieldLevel) error {
+		switch fl.Field().Kind() {
+		case reflect.String:
+			s, err := fn(ctx, fl.Field().String(), fl.Param())
+			if err != nil {
+				return err
+			}
+			fl.Field().SetString(s)
+		}
+		return nil
+	}
+}
@@ -3,11 +3,8 @@ package scrubbers
 import (
 	"context"
 	"fmt"
-	"reflect"
 	"regexp"
 	"strings"
-
-	"github.com/pchchv/modifier"
 )
 
 var (
@@ -17,25 +14,15 @@ var (
 		scrubbed := fmt.Sprintf("<<scrubbed::email::sha1::%s>>", hashString(input[idx:]))
 		return scrubbed + input[idx:]
 	}
-	textFn = func(shaName string) modifier.Func {
+	textFn = func(shaName string) StringFunc {
 		// text scrubs the whole text for PII compliance
-		return func(ctx context.Context, fl modifier.FieldLevel) error {
-			switch fl.Field().Kind() {
-			case reflect.String:
-				scrubbed := fmt.Sprintf("<<scrubbed::%s::sha1::%s>>", shaName, hashString(fl.Field().String()))
-				fl.Field().SetString(scrubbed)
-			}
-			return nil
+		return func(_ context.Context, s, _ string) (string, error) {
+			return fmt.Sprintf("<<scrubbed::%s::sha1::%s>>", shaName, hashString(s)), nil
 		}
 	}
 )
 
 // emails scrubs all emails found for PII compliance
-func emails(ctx context.Context, fl modifier.FieldLevel) error {
-	switch fl.Field().Kind() {
-	case reflect.String:
-		scrubbed := emailRegex.ReplaceAllStringFunc(fl.Field().String(), emailSubmatchFn)
-		fl.Field().SetString(scrubbed)
-	}
-	return nil
+func emails(_ context.Context, s, _ string) (string, error) {
+	return emailRegex.ReplaceAllStringFunc(s, emailSubmatchFn), nil
 }
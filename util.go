@@ -1,6 +1,11 @@
 package modifier
 
-import "reflect"
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
 
 // extractType gets the actual underlying type of field value.
 func (t *Transformer) extractType(current reflect.Value) (reflect.Value, reflect.Kind) {
@@ -26,6 +31,299 @@ func (t *Transformer) extractType(current reflect.Value) (reflect.Value, reflect
 	}
 }
 
+// commit calls the CommitFuncs of the intercepted values current refers to,
+// the innermost first, as extractType follows them, after the transformation tag was applied.
+func (t *Transformer) commit(current reflect.Value, tag string, changed bool) {
+	if len(t.commits) == 0 {
+		return
+	}
+
+	switch current.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !current.IsNil() {
+			t.commit(current.Elem(), tag, changed)
+		}
+	default:
+		if fn := t.interceptors[current.Type()]; fn != nil {
+			inner := fn(current)
+			t.commit(inner, tag, changed)
+			if commit := t.commits[current.Type()]; commit != nil {
+				commit(current, inner, tag, changed)
+			}
+		}
+	}
+}
+
+// save returns a copy of current, which is about to be transformed,
+// if CommitFuncs are registered that need to know whether it changed.
+func (t *Transformer) save(current reflect.Value) reflect.Value {
+	if len(t.commits) == 0 {
+		return reflect.Value{}
+	}
+
+	saved := reflect.New(current.Type()).Elem()
+	saved.Set(current)
+	return saved
+}
+
+// changed reports whether current differs from the value saved before it was transformed,
+// values which cannot be compared are assumed to be changed.
+func changed(saved, current reflect.Value) bool {
+	if !saved.IsValid() {
+		return false
+	}
+	return !saved.Comparable() || !saved.Equal(current)
+}
+
+// moldable reports whether current is a non nil value implementing Moldable,
+// with a pointer or value receiver. p is the path of current,
+// for struct fields it's known from the type of the field.
+func (t *Transformer) moldable(current reflect.Value, p fieldPath) bool {
+	switch current.Kind() {
+	case reflect.Invalid, reflect.Ptr, reflect.Interface:
+		return false
+	}
+
+	if p.kind == segmentField && p.cf.mold != moldDynamic {
+		return p.cf.mold == moldAlways
+	}
+	return t.mCache.moldable(current.Type())
+}
+
+// isMoldable reports whether values of typ implement Moldable, with a pointer or value receiver.
+func isMoldable(typ reflect.Type) bool {
+	return typ.Kind() != reflect.Ptr && typ.Kind() != reflect.Interface && reflect.PointerTo(typ).Implements(moldableType)
+}
+
+// fieldMold returns whether the values of a struct field of type typ implement Moldable,
+// or moldDynamic if it depends on the value, because of interfaces or interceptors.
+func (t *Transformer) fieldMold(typ reflect.Type) moldCheck {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ.Kind() == reflect.Interface || t.interceptors[typ] != nil:
+		return moldDynamic
+	case isMoldable(typ):
+		return moldAlways
+	}
+	return moldNever
+}
+
+// containsMoldable reports whether values of typ may contain a value implementing Moldable.
+// Values of interfaces are not known, so they are assumed not to.
+func containsMoldable(typ reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[typ] {
+		return false
+	}
+
+	seen[typ] = true
+	if isMoldable(typ) {
+		return true
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return containsMoldable(typ.Elem(), seen)
+	case reflect.Map:
+		return containsMoldable(typ.Key(), seen) || containsMoldable(typ.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if fld := typ.Field(i); (fld.Anonymous || len(fld.PkgPath) == 0) && containsMoldable(fld.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recursive reports whether typ, or a type it contains, is reachable from itself through pointers,
+// slices, arrays, maps and struct fields, so that it's values may contain cycles.
+// The types on the way to typ are in path, done are the types known not to be recursive.
+func recursive(typ reflect.Type, path, done map[reflect.Type]bool) bool {
+	if path[typ] {
+		return true
+	}
+
+	if done[typ] {
+		return false
+	}
+
+	path[typ] = true
+	defer delete(path, typ)
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		if recursive(typ.Elem(), path, done) {
+			return true
+		}
+	case reflect.Map:
+		if recursive(typ.Key(), path, done) || recursive(typ.Elem(), path, done) {
+			return true
+		}
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if fld := typ.Field(i); (fld.Anonymous || len(fld.PkgPath) == 0) && recursive(fld.Type, path, done) {
+				return true
+			}
+		}
+	}
+
+	done[typ] = true
+	return false
+}
+
+// filterNames returns the fields passed to StructPartial or StructExcept
+// prefixed with the name of the struct type of v, as they appear in struct namespaces.
+func filterNames(v interface{}, fields []string) []string {
+	typ := reflect.TypeOf(v)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || len(typ.Name()) == 0 {
+		return fields
+	}
+
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = typ.Name() + string(namespaceSeparator) + field
+	}
+	return names
+}
+
+// within reports whether the namespace ns is name or a value within it
+// e. g. User.Address.City is within User.Address, but not within User.Addr.
+func within(ns []byte, name string) bool {
+	if len(ns) < len(name) || string(ns[:len(name)]) != name {
+		return false
+	}
+	return len(ns) == len(name) || ns[len(name)] == namespaceSeparator || ns[len(name)] == '['
+}
+
+// getStructFieldOK traverses val following the namespace
+// and returns the value, kind and whether it was found.
+func (t *Transformer) getStructFieldOK(val reflect.Value, namespace string) (reflect.Value, reflect.Kind, bool) {
+	current, kind := t.extractType(val)
+	if len(namespace) == 0 {
+		return current, kind, kind != reflect.Ptr && kind != reflect.Interface
+	}
+
+	switch kind {
+	case reflect.Struct:
+		if namespace[0] == '[' {
+			break
+		}
+
+		fld, rest := namespace, ""
+		if idx := strings.IndexAny(namespace, ".["); idx != -1 {
+			fld, rest = namespace[:idx], namespace[idx:]
+			if rest[0] == namespaceSeparator {
+				rest = rest[1:]
+			}
+		}
+
+		sf, ok := current.Type().FieldByName(fld)
+		if !ok || len(sf.PkgPath) > 0 {
+			break
+		}
+
+		v, err := current.FieldByIndexErr(sf.Index)
+		if err != nil {
+			break
+		}
+
+		return t.getStructFieldOK(v, rest)
+	case reflect.Array, reflect.Slice, reflect.Map:
+		idx := strings.IndexByte(namespace, ']')
+		if namespace[0] != '[' || idx == -1 {
+			break
+		}
+
+		key, rest := namespace[1:idx], strings.TrimPrefix(namespace[idx+1:], ".")
+		if kind == reflect.Map {
+			k, ok := parseMapKey(current.Type().Key(), key)
+			if !ok {
+				break
+			}
+
+			v := current.MapIndex(k)
+			if !v.IsValid() {
+				break
+			}
+
+			return t.getStructFieldOK(v, rest)
+		}
+
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= current.Len() {
+			break
+		}
+
+		return t.getStructFieldOK(current.Index(i), rest)
+	}
+
+	return current, kind, false
+}
+
+// evalGuard reports whether the condition of an if/unless tag holds.
+// The param is either the name of a sibling field, which must have a value,
+// or the name and the value it must equal separated by a colon e. g. Country:DE.
+func (t *Transformer) evalGuard(p fieldPath, param string) bool {
+	if !p.structValue.IsValid() {
+		return false
+	}
+
+	name, value, hasValue := strings.Cut(param, string(guardSeparator))
+	current, _, ok := t.getStructFieldOK(p.structValue, name)
+	if !ok || !current.CanInterface() {
+		return false
+	}
+
+	if !hasValue {
+		return !current.IsZero()
+	}
+
+	return fmt.Sprint(current.Interface()) == value
+}
+
+// parseMapKey converts key into a value of the map key type typ.
+func parseMapKey(typ reflect.Type, key string) (reflect.Value, bool) {
+	v := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.String:
+		v.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(key, 10, typ.Bits())
+		if err != nil {
+			return v, false
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(key, 10, typ.Bits())
+		if err != nil {
+			return v, false
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(key, typ.Bits())
+		if err != nil {
+			return v, false
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(key)
+		if err != nil {
+			return v, false
+		}
+		v.SetBool(b)
+	default:
+		return v, false
+	}
+
+	return v, true
+}
+
 // HasValue determines if a reflect.Value is it's default value.
 func HasValue(field reflect.Value) bool {
 	switch field.Kind() {
@@ -35,3 +333,65 @@ func HasValue(field reflect.Value) bool {
 		return field.IsValid() && field.Interface() != reflect.Zero(field.Type()).Interface()
 	}
 }
+
+// ruleStruct returns the struct type of v, which may be a pointer to it, struct rules are registered for.
+func ruleStruct(v interface{}) (reflect.Type, error) {
+	typ := reflect.TypeOf(v)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("struct rules cannot be registered for %v", typ)
+	}
+	return typ, nil
+}
+
+// conflictingRule returns an error if a rule other than tags is registered for the field of the struct type st,
+// which the rule name for typ refers to.
+func (t *Transformer) conflictingRule(typ, st reflect.Type, name, field, tags string) error {
+	if registered, ok := t.structRules[st][field]; ok && registered != tags {
+		return fmt.Errorf("struct rule '%s' of %s conflicts with the rule '%s' registered for field '%s' of %s", name, typ, registered, field, st)
+	}
+	return nil
+}
+
+// resolveRuleField returns the struct type declaring the field
+// the dot separated name refers to starting from typ, along with the name of the field.
+func resolveRuleField(typ reflect.Type, name string) (reflect.Type, string, error) {
+	st := typ
+	names := strings.Split(name, string(namespaceSeparator))
+	for i, n := range names {
+		fld, ok := st.FieldByName(n)
+		if !ok || !fld.Anonymous && len(fld.PkgPath) > 0 {
+			return nil, "", fmt.Errorf("field '%s' of struct rule '%s' not found in %s", n, name, typ)
+		}
+
+		// promoted fields are declared by an embedded struct
+		for _, idx := range fld.Index[:len(fld.Index)-1] {
+			st = st.Field(idx).Type
+			for st.Kind() == reflect.Ptr {
+				st = st.Elem()
+			}
+		}
+
+		if i == len(names)-1 {
+			return st, fld.Name, nil
+		}
+
+		st = fld.Type
+		for {
+			switch st.Kind() {
+			case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+				st = st.Elem()
+				continue
+			}
+			break
+		}
+
+		if st.Kind() != reflect.Struct {
+			return nil, "", fmt.Errorf("field '%s' of struct rule '%s' in %s is no struct", n, name, typ)
+		}
+	}
+	return st, name, nil
+}